@@ -3,23 +3,26 @@ package main
 import (
 	"flag"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
-	_ "strings"
+	"strings"
 	"time"
 
-	"bytes"
-	"encoding/binary"
 	"fmt"
-	"net"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/joho/godotenv"
 
+	"test/modules/artifact"
 	"test/modules/crypto"
+	"test/modules/discord"
 	"test/modules/doujin"
 	"test/modules/math"
+	"test/modules/minecraft"
+	"test/modules/permissions"
+	"test/modules/storage"
 )
 
 // use godot package to load/read the .env file and
@@ -43,6 +46,21 @@ var (
 	GuildID        = flag.String("guild", "", "Test guild ID. If not passed - bot registers commands globally")
 	BotToken       = flag.String("token", goDotEnvVariable("TOKEN"), "Bot access token")
 	RemoveCommands = flag.Bool("rmcmd", true, "Remove all commands after shutdowning or not")
+	DataDir        = flag.String("data", storage.Dir, "Directory persisted module data is stored in")
+	Owners         = flag.String("owners", "", "Comma separated user IDs of the bot owners, who may use owner-only commands")
+
+	ArtifactDir       = flag.String("artifacts", "./artifacts", "Directory for stored command outputs. If empty - storing outputs is disabled")
+	ArtifactQuota     = flag.Int64("artifact-quota", 256*1024*1024, "Maximum total size of stored outputs in bytes (0 for unlimited)")
+	ArtifactRetention = flag.Duration("artifact-retention", 24*time.Hour, "How long stored outputs are kept (0 to keep forever)")
+	ArtifactListen    = flag.String("artifact-listen", "", "Address to serve stored outputs over HTTP on, e.g. :8080. If empty - large outputs are uploaded in parts")
+	ArtifactURL       = flag.String("artifact-url", "", "Public base URL the -artifact-listen server is reachable under")
+
+	DownloadDir     = flag.String("downloads", doujin.Cache.Root(), "Directory doujin pages are downloaded to")
+	DownloadCache   = flag.Int64("download-cache", 2*1024*1024*1024, "Maximum total size of downloaded doujin pages in bytes (0 for unlimited)")
+	DoujinAnywhere  = flag.Bool("doujin-sfw-channels", false, "Allow the doujin module in channels that are not marked NSFW")
+	DoujinDMs       = flag.Bool("doujin-dms", false, "Allow the doujin module in direct messages")
+	ReaderTTL       = flag.Duration("reader-ttl", doujin.SessionTTL, "How long an idle doujin reader stays open")
+	CollatzMaxCount = flag.Int("collatz-max", math.MaxInputCount, "Maximum amount of numbers a single /collatzconjecture input may expand to")
 )
 
 var s *discordgo.Session
@@ -76,10 +94,64 @@ func main() {
 
 	log.Println("Bot starting up...")
 
+	if *ArtifactDir != "" {
+		store, err := artifact.NewLocalStore(*ArtifactDir, *ArtifactQuota, *ArtifactRetention)
+		if err != nil {
+			log.Fatalf("Cannot open the artifact store: %v", err)
+		}
+		artifact.SetDefault(store)
+
+		if *ArtifactListen != "" && *ArtifactURL == "" {
+			log.Printf("Not serving stored outputs: -artifact-listen needs -artifact-url")
+		} else if *ArtifactListen != "" {
+			artifact.PublicURL = *ArtifactURL
+			go func() {
+				if err := http.ListenAndServe(*ArtifactListen, artifact.Handler(store)); err != nil {
+					log.Printf("Artifact server stopped: %v", err)
+				}
+			}()
+		}
+
+		// remove expired outputs even when nothing new is stored
+		go func() {
+			for range time.Tick(1 * time.Hour) {
+				store.Prune()
+			}
+		}()
+	}
+
+	storage.Dir = *DataDir
+	if *Owners != "" {
+		discord.Owners = strings.Split(*Owners, ",")
+	}
+	math.MaxInputCount = *CollatzMaxCount
+	doujin.SessionTTL = *ReaderTTL
+	doujin.AllowNonNSFWChannels = *DoujinAnywhere
+	doujin.AllowDMs = *DoujinDMs
+	doujin.Cache = doujin.NewPageCache(*DownloadDir, *DownloadCache)
+
 	// Register command handlers
-	doujin.RegisterDoujinHandler(s)
-	crypto.RegisterCryptoHandler(s)
-	math.RegisterCollatzConjectureHandler(s)
+	router := discord.NewRouter(discord.New(s))
+	router.Use(discord.Recover(), discord.Logging(), discord.Timing(2*time.Second))
+	doujin.RegisterDoujinHandler(router)
+	crypto.RegisterCryptoHandler(router)
+	math.RegisterCollatzConjectureHandler(router)
+	artifact.RegisterResultHandler(router)
+	minecraft.RegisterMinecraftHandler(router)
+	permissions.RegisterPermissionsHandler(router)
+	router.Attach(s)
+
+	// commands registered in the test guild carry its /permissions overrides,
+	// global ones are the same everywhere and can not follow them
+	if *GuildID != "" {
+		permissions.SyncCommand = func(guildID string, def *discordgo.ApplicationCommand) error {
+			if guildID != *GuildID {
+				return nil
+			}
+			_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, def)
+			return err
+		}
+	}
 
 	s.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
 		log.Printf("Logged in as: %v#%v", s.State.User.Username, s.State.User.Discriminator)
@@ -91,37 +163,18 @@ func main() {
 	}
 
 	log.Println("Adding commands...")
-	registeredCommands := make([]*discordgo.ApplicationCommand, len(doujin.DoujinCommand), len(crypto.CryptoCommand))
-	for i, v := range doujin.DoujinCommand {
-		cmd, err := s.ApplicationCommandCreate(s.State.User.ID, *GuildID, v)
-		if err != nil {
-			log.Panicf("Cannot create '%v' command: %v", v.Name, err)
-		}
-		registeredCommands[i] = cmd
-		log.Printf("Added '%v' command: %v", v.Name, v.Description)
-	}
-	for _, v := range crypto.CryptoCommand {
+	var registeredCommands []*discordgo.ApplicationCommand
+	for _, v := range router.CommandsFor(*GuildID) {
 		cmd, err := s.ApplicationCommandCreate(s.State.User.ID, *GuildID, v)
 		if err != nil {
-			log.Panicf("Cannot create '%v' command: %v", v.Name, err)
-		}
-		registeredCommands = append(registeredCommands, cmd)
-		log.Printf("Added '%v' command: %v", v.Name, v.Description)
-	}
-	for _, v := range math.CalculateCommand {
-		cmd, err := s.ApplicationCommandCreate(s.State.User.ID, *GuildID, v)
-		if err != nil {
-			log.Panicf("Cannot create '%v' command: %v", v.Name, err)
+			// keep the other commands working
+			log.Printf("Cannot create '%v' command: %v", v.Name, err)
+			continue
 		}
 		registeredCommands = append(registeredCommands, cmd)
 		log.Printf("Added '%v' command: %v", v.Name, v.Description)
 	}
 
-	server := "luckynetwork.net"
-	port := uint16(25565)
-	username := "nigergamer"
-
-	startRoutine(server, port, username)
 	log.Println("Bot is now running.  Press CTRL-C to exit.")
 
 	defer s.Close()
@@ -131,96 +184,18 @@ func main() {
 	log.Println("Press Ctrl+C to exit")
 	<-stop
 
+	doujin.SaveHistory()
+	minecraft.SaveWatches()
+
 	if *RemoveCommands {
 		log.Println("Removing commands...")
 		for _, v := range registeredCommands {
 			err := s.ApplicationCommandDelete(s.State.User.ID, *GuildID, v.ID)
 			if err != nil {
-				log.Panicf("Cannot delete '%v' command: %v", v.Name, err)
+				log.Printf("Cannot delete '%v' command: %v", v.Name, err)
 			}
 		}
 	}
 
 	log.Println("Gracefully shutting down.")
 }
-
-func writeVarInt(buf *bytes.Buffer, value int) {
-	for {
-		temp := byte(value & 0x7F)
-		value >>= 7
-		if value != 0 {
-			temp |= 0x80
-		}
-		buf.WriteByte(temp)
-		if value == 0 {
-			break
-		}
-	}
-}
-
-func writeString(buf *bytes.Buffer, s string) {
-	writeVarInt(buf, len(s))
-	buf.WriteString(s)
-}
-
-func sendMinecraftHandshake(conn net.Conn, serverAddr string, port uint16, username string) error {
-	// Handshake packet
-	handshake := &bytes.Buffer{}
-	writeVarInt(handshake, 0x00)       // Packet ID
-	writeVarInt(handshake, 754)        // Protocol version
-	writeString(handshake, serverAddr) // Server address
-	writeString(handshake, "ily faced")
-	err := binary.Write(handshake, binary.BigEndian, port)
-	if err != nil {
-		return err
-	} // Port
-	writeVarInt(handshake, 2) // Next state: login
-
-	// Send handshake
-	packet := &bytes.Buffer{}
-	writeVarInt(packet, handshake.Len())
-	packet.Write(handshake.Bytes())
-	if _, err := conn.Write(packet.Bytes()); err != nil {
-		return err
-	}
-
-	// Login Start packet
-	login := &bytes.Buffer{}
-	writeVarInt(login, 0x00)     // Packet ID
-	writeString(login, username) // Username
-	fmt.Fprintf(conn, handshake.String(), handshake.String())
-	log.Printf("Handshake sent")
-
-	packet.Reset()
-	writeVarInt(packet, login.Len())
-	packet.Write(login.Bytes())
-	if _, err := conn.Write(packet.Bytes()); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func startRoutine(server string, port uint16, username string) {
-	go func() {
-		for {
-			conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", server, port))
-			if err != nil {
-				fmt.Println("Connection error:", err)
-				time.Sleep(2 * time.Second)
-				continue
-			}
-
-			err = sendMinecraftHandshake(conn, server, port, username)
-			log.Println("Sent handshake to", server)
-			if err != nil {
-				fmt.Println("Packet error:", err)
-			} else {
-				fmt.Println("Packets sent successfully.")
-			}
-
-			conn.Close()
-			time.Sleep(2 * time.Second) // Repeat every 10 seconds
-		}
-	}()
-}
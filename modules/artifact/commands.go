@@ -0,0 +1,40 @@
+package artifact
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+var ResultCommand = []*discordgo.ApplicationCommand{
+	{
+		Name:        "result",
+		Description: "Download a stored command output by its ID",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "id",
+				Description: "The result ID given by the command that produced it",
+				Required:    true,
+			},
+		},
+	},
+}
+
+var StoreCommand = []*discordgo.ApplicationCommand{
+	{
+		Name:        "artifacts",
+		Description: "Remove expired stored outputs and show how much space the rest use",
+	},
+}
+
+// StoreAccess limits /artifacts to the bot owners, the store is shared by
+// every server the bot is in
+var StoreAccess = discord.Access{OwnerOnly: true}
+
+// ResultLimits bounds how often /result uploads stored outputs
+var ResultLimits = discord.Limits{
+	PerUser: discord.Bucket{Burst: 5, Every: 30 * time.Second},
+}
@@ -0,0 +1,97 @@
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MaxParts is how many attachments /result splits a large artifact into
+// when it can not be served over HTTP
+const MaxParts = 10
+
+// PublicURL is the base URL Handler is reachable under. When set, artifacts
+// of any size can be downloaded from it; otherwise /result uploads large ones
+// in parts
+var PublicURL = ""
+
+// ErrTooLarge is returned by Keep for outputs no download path can deliver
+var ErrTooLarge = errors.New("artifact is too large to be downloaded")
+
+// MaxFetchable returns the size of the largest artifact users can download,
+// 0 when there is no limit
+func MaxFetchable() int64 {
+	if PublicURL != "" {
+		return 0
+	}
+	return UploadLimit * MaxParts
+}
+
+// Keep stores the content of r in the default store, refusing content larger
+// than MaxFetchable so every reference handed out can be used
+func Keep(name, contentType string, r io.Reader) (*Artifact, error) {
+	if limit := MaxFetchable(); limit > 0 {
+		r = &limitedReader{r: r, left: limit}
+	}
+	return Default().Put(name, contentType, r)
+}
+
+// limitedReader fails instead of stopping once more than left bytes are read
+type limitedReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+// URL returns where the artifact can be downloaded over HTTP, empty when
+// PublicURL is not set
+func URL(a *Artifact) string {
+	if PublicURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(PublicURL, "/") + "/" + a.ID
+}
+
+// Reference returns the message fragment pointing users to a stored artifact
+func Reference(a *Artifact) string {
+	if url := URL(a); url != "" {
+		return fmt.Sprintf("Full output saved, download it from %s or with `/result %s`", url, a.ID)
+	}
+	return fmt.Sprintf("Full output saved, download it again with `/result %s`", a.ID)
+}
+
+// Handler serves the artifacts of store under /<id>
+func Handler(store Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := strings.ToLower(strings.Trim(r.URL.Path, "/"))
+		a, rc, err := store.Open(id)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer rc.Close()
+
+		w.Header().Set("Content-Type", a.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.Name))
+		if r.Method == http.MethodGet {
+			io.Copy(w, rc)
+		}
+	})
+}
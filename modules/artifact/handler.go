@@ -0,0 +1,131 @@
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+func RegisterResultHandler(r *discord.Router) {
+	r.Command(ResultCommand[0], handleResultCommand, discord.RateLimit(ResultLimits))
+	r.Command(StoreCommand[0], handleStoreCommand)
+	r.Restrict(StoreCommand[0], StoreAccess)
+}
+
+// handleResultCommand processes the /result command
+func handleResultCommand(s discord.Client, i *discordgo.InteractionCreate) {
+	if !isResultCommand(i) {
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		discord.RespondEphemeral(s, i, "❌ Please provide a result ID")
+		return
+	}
+	id := strings.ToLower(strings.TrimSpace(options[0].StringValue()))
+
+	artifact, rc, err := Default().Open(id)
+	if err != nil {
+		if errors.Is(err, ErrDisabled) {
+			discord.RespondEphemeral(s, i, "❌ Stored results are disabled on this bot")
+		} else {
+			discord.RespondEphemeral(s, i, fmt.Sprintf("❌ Result `%s` not found or expired", id))
+		}
+		return
+	}
+	defer rc.Close()
+
+	if artifact.Size > UploadLimit {
+		sendLarge(s, i, artifact, rc)
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("📎 Result `%s`", artifact.ID),
+			Files: []*discordgo.File{
+				{
+					Name:        artifact.Name,
+					ContentType: artifact.ContentType,
+					Reader:      rc,
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to send result %s: %v", artifact.ID, err)
+	}
+}
+
+// sendLarge links to an artifact too large to attach, or uploads it in
+// parts of at most UploadLimit bytes when there is no HTTP download
+func sendLarge(s discord.Client, i *discordgo.InteractionCreate, a *Artifact, rc io.Reader) {
+	if url := URL(a); url != "" {
+		discord.RespondEphemeral(s, i, fmt.Sprintf("📎 Result `%s` is too large to attach, download it from %s", a.ID, url))
+		return
+	}
+
+	parts := int((a.Size + UploadLimit - 1) / UploadLimit)
+	if parts > MaxParts {
+		discord.RespondEphemeral(s, i, fmt.Sprintf("❌ Result `%s` is too large to upload (%d bytes)", a.ID, a.Size))
+		return
+	}
+
+	if err := discord.Respond(s, i, fmt.Sprintf("📎 Result `%s` is uploaded in %d parts, join them with `cat %s.part* > %s`",
+		a.ID, parts, a.Name, a.Name)); err != nil {
+		log.Printf("Failed to send result %s: %v", a.ID, err)
+		return
+	}
+	for n := 1; n <= parts; n++ {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Files: []*discordgo.File{
+				{
+					Name:        fmt.Sprintf("%s.part%02d", a.Name, n),
+					ContentType: "application/octet-stream",
+					Reader:      io.LimitReader(rc, UploadLimit),
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send part %d of result %s: %v", n, a.ID, err)
+			discord.FollowupEphemeral(s, i, fmt.Sprintf("❌ Failed to upload part %d, please try again", n))
+			return
+		}
+	}
+}
+
+// handleStoreCommand processes the /artifacts command
+func handleStoreCommand(s discord.Client, i *discordgo.InteractionCreate) {
+	store, ok := Default().(*LocalStore)
+	if !ok {
+		discord.RespondEphemeral(s, i, "❌ Stored results are disabled on this bot")
+		return
+	}
+
+	store.Prune()
+	count, size, quota := store.Usage()
+	limit := "no quota"
+	if quota > 0 {
+		limit = fmt.Sprintf("%.1f%% of the %s quota", float64(size)*100/float64(quota), mebibytes(quota))
+	}
+	discord.RespondEphemeral(s, i, fmt.Sprintf("🗄️ %d stored output(s) using %s, %s", count, mebibytes(size), limit))
+}
+
+func mebibytes(n int64) string {
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+}
+
+func isResultCommand(i *discordgo.InteractionCreate) bool {
+	return i != nil &&
+		i.Interaction != nil &&
+		i.Type == discordgo.InteractionApplicationCommand &&
+		i.ApplicationCommandData().Name == "result"
+}
@@ -0,0 +1,139 @@
+package artifact
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+func useStore(t *testing.T) *LocalStore {
+	t.Helper()
+	store, err := NewLocalStore(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDefault(store)
+	t.Cleanup(func() { SetDefault(DisabledStore{}) })
+	return store
+}
+
+func resultInteraction(id string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:   "1",
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "result",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "id", Type: discordgo.ApplicationCommandOptionString, Value: id},
+			},
+		},
+	}}
+}
+
+func TestKeepRefusesUnfetchable(t *testing.T) {
+	useStore(t)
+
+	_, err := Keep("big.bin", "application/octet-stream", io.LimitReader(zeros{}, MaxFetchable()+1))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Keep() error = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestResultUploadsLargeArtifactInParts(t *testing.T) {
+	useStore(t)
+
+	content := bytes.Repeat([]byte("x"), 2*UploadLimit+5) // a bit over two parts
+	a, err := Keep("out.txt", "text/plain", bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := discord.NewRecorder()
+	handleResultCommand(rec, resultInteraction(a.ID))
+
+	followups := rec.Calls("FollowupMessageCreate")
+	if len(followups) != 3 {
+		t.Fatalf("got %d parts, want 3", len(followups))
+	}
+	var joined []byte
+	for n, call := range followups {
+		file := call.Args[2].(*discordgo.WebhookParams).Files[0]
+		data, err := io.ReadAll(file.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > UploadLimit {
+			t.Errorf("part %d is %d bytes, over the upload limit", n+1, len(data))
+		}
+		joined = append(joined, data...)
+	}
+	if !bytes.Equal(joined, content) {
+		t.Error("joined parts differ from the stored content")
+	}
+}
+
+func TestResultLinksWhenServedOverHTTP(t *testing.T) {
+	store := useStore(t)
+	PublicURL = "https://example.com/results"
+	t.Cleanup(func() { PublicURL = "" })
+
+	a, err := Keep("out.txt", "text/plain", io.LimitReader(zeros{}, UploadLimit+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := discord.NewRecorder()
+	handleResultCommand(rec, resultInteraction(a.ID))
+	resp := rec.Calls("InteractionRespond")[0].Args[1].(*discordgo.InteractionResponse)
+	if want := URL(a); !bytes.Contains([]byte(resp.Data.Content), []byte(want)) {
+		t.Errorf("reply %q does not link to %s", resp.Data.Content, want)
+	}
+
+	w := httptest.NewRecorder()
+	Handler(store).ServeHTTP(w, httptest.NewRequest("GET", "/"+a.ID, nil))
+	if w.Code != 200 || int64(w.Body.Len()) != a.Size {
+		t.Errorf("GET /%s = %d with %d bytes, want 200 with %d", a.ID, w.Code, w.Body.Len(), a.Size)
+	}
+}
+
+func TestStoreCommandIsOwnerOnly(t *testing.T) {
+	useStore(t)
+	if _, err := Keep("out.txt", "text/plain", bytes.NewReader(make([]byte, 1<<20))); err != nil {
+		t.Fatal(err)
+	}
+	owners := discord.Owners
+	discord.Owners = []string{"owner"}
+	t.Cleanup(func() { discord.Owners = owners })
+
+	rec := discord.NewRecorder()
+	router := discord.NewRouter(rec)
+	RegisterResultHandler(router)
+
+	for _, userID := range []string{"admin", "owner"} {
+		rec.Reset()
+		router.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			ID:     "1",
+			Type:   discordgo.InteractionApplicationCommand,
+			Member: &discordgo.Member{User: &discordgo.User{ID: userID}, Permissions: discordgo.PermissionAdministrator},
+			Data:   discordgo.ApplicationCommandInteractionData{Name: "artifacts"},
+		}})
+		content := rec.Calls("InteractionRespond")[0].Args[1].(*discordgo.InteractionResponse).Data.Content
+		if want := "1 stored output(s) using 1.0 MiB"; (userID == "owner") != strings.Contains(content, want) {
+			t.Errorf("%s got %q", userID, content)
+		}
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
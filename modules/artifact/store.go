@@ -0,0 +1,285 @@
+package artifact
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UploadLimit is the Discord attachment size limit for normal bots.
+const UploadLimit = 8 * 1024 * 1024 // 8 MB
+
+var (
+	ErrDisabled      = errors.New("artifact store is disabled")
+	ErrNotFound      = errors.New("artifact not found or expired")
+	ErrQuotaExceeded = errors.New("artifact is larger than the store quota")
+)
+
+// Artifact describes a stored command output
+type Artifact struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Store keeps large command outputs so users can download them again with /result
+type Store interface {
+	// Put stores the content of r and returns a reference to it
+	Put(name, contentType string, r io.Reader) (*Artifact, error)
+	// Open returns the artifact metadata and a reader for its content
+	Open(id string) (*Artifact, io.ReadCloser, error)
+}
+
+var (
+	defaultMutex sync.RWMutex
+	defaultStore Store = DisabledStore{}
+)
+
+// SetDefault sets the store used by all modules
+func SetDefault(store Store) {
+	defaultMutex.Lock()
+	defaultStore = store
+	defaultMutex.Unlock()
+}
+
+// Default returns the store used by all modules
+func Default() Store {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultStore
+}
+
+// DisabledStore refuses to store anything
+type DisabledStore struct{}
+
+func (DisabledStore) Put(name, contentType string, r io.Reader) (*Artifact, error) {
+	return nil, ErrDisabled
+}
+
+func (DisabledStore) Open(id string) (*Artifact, io.ReadCloser, error) {
+	return nil, nil, ErrDisabled
+}
+
+// LocalStore keeps artifacts in a local directory. Artifacts older than
+// retention are removed, and the oldest ones are evicted once quota is reached.
+type LocalStore struct {
+	dir       string
+	quota     int64
+	retention time.Duration
+	mu        sync.Mutex
+}
+
+// NewLocalStore creates the directory if needed and returns a store backed by it.
+// A quota or retention of zero means unlimited.
+func NewLocalStore(dir string, quota int64, retention time.Duration) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	return &LocalStore{dir: dir, quota: quota, retention: retention}, nil
+}
+
+// Put writes the content to the store, evicting old artifacts to stay under quota
+func (l *LocalStore) Put(name, contentType string, r io.Reader) (*Artifact, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(l.dir, "upload-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create artifact: %w", err)
+	}
+	size, err := io.Copy(tmp, r)
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if l.quota > 0 && size > l.quota {
+		os.Remove(tmp.Name())
+		return nil, ErrQuotaExceeded
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.pruneLocked(size)
+
+	artifact := &Artifact{
+		ID:          id,
+		Name:        filepath.Base(name),
+		ContentType: contentType,
+		Size:        size,
+		CreatedAt:   time.Now(),
+	}
+	meta, err := json.Marshal(artifact)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	if err := os.WriteFile(l.metaPath(id), meta, 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to write artifact metadata: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.dataPath(id)); err != nil {
+		os.Remove(tmp.Name())
+		os.Remove(l.metaPath(id))
+		return nil, fmt.Errorf("failed to store artifact: %w", err)
+	}
+
+	return artifact, nil
+}
+
+// Open returns the artifact with the given ID if it has not expired
+func (l *LocalStore) Open(id string) (*Artifact, io.ReadCloser, error) {
+	if !validID(id) {
+		return nil, nil, ErrNotFound
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	artifact, err := l.readMeta(id)
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+	if l.expired(artifact) {
+		l.remove(id)
+		return nil, nil, ErrNotFound
+	}
+
+	f, err := os.Open(l.dataPath(id))
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+	return artifact, f, nil
+}
+
+// Prune removes expired artifacts
+func (l *LocalStore) Prune() {
+	l.mu.Lock()
+	l.pruneLocked(0)
+	l.mu.Unlock()
+}
+
+// Usage returns how many unexpired artifacts are stored, their total size and
+// the quota, 0 when unlimited
+func (l *LocalStore) Usage() (count int, size, quota int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, a := range l.list() {
+		if !l.expired(a) {
+			count++
+			size += a.Size
+		}
+	}
+	return count, size, l.quota
+}
+
+// pruneLocked removes expired artifacts, then evicts the oldest ones until
+// incoming bytes fit in the quota
+func (l *LocalStore) pruneLocked(incoming int64) {
+	artifacts := l.list()
+
+	var used int64
+	kept := artifacts[:0]
+	for _, a := range artifacts {
+		if l.expired(a) {
+			l.remove(a.ID)
+			continue
+		}
+		used += a.Size
+		kept = append(kept, a)
+	}
+
+	if l.quota <= 0 {
+		return
+	}
+
+	sort.Slice(kept, func(i, j int) bool { return kept[i].CreatedAt.Before(kept[j].CreatedAt) })
+	for _, a := range kept {
+		if used+incoming <= l.quota {
+			break
+		}
+		l.remove(a.ID)
+		used -= a.Size
+	}
+}
+
+func (l *LocalStore) list() []*Artifact {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil
+	}
+
+	var artifacts []*Artifact
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !validID(id) {
+			continue
+		}
+		if a, err := l.readMeta(id); err == nil {
+			artifacts = append(artifacts, a)
+		}
+	}
+	return artifacts
+}
+
+func (l *LocalStore) readMeta(id string) (*Artifact, error) {
+	data, err := os.ReadFile(l.metaPath(id))
+	if err != nil {
+		return nil, err
+	}
+	var artifact Artifact
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return nil, err
+	}
+	return &artifact, nil
+}
+
+func (l *LocalStore) expired(a *Artifact) bool {
+	return l.retention > 0 && time.Since(a.CreatedAt) > l.retention
+}
+
+func (l *LocalStore) remove(id string) {
+	os.Remove(l.dataPath(id))
+	os.Remove(l.metaPath(id))
+}
+
+func (l *LocalStore) dataPath(id string) string {
+	return filepath.Join(l.dir, id+".bin")
+}
+
+func (l *LocalStore) metaPath(id string) string {
+	return filepath.Join(l.dir, id+".json")
+}
+
+// newID returns a short random hex identifier
+func newID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate artifact ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validID rejects anything that is not an ID generated by newID,
+// so user input can never escape the store directory
+func validID(id string) bool {
+	if len(id) != 12 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
@@ -1,6 +1,12 @@
 package crypto
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
 
 var CryptoCommand = []*discordgo.ApplicationCommand{
 	{
@@ -16,3 +22,9 @@ var CryptoCommand = []*discordgo.ApplicationCommand{
 		},
 	},
 }
+
+// CryptoLimits bounds how often /track runs, every call queries the price API
+var CryptoLimits = discord.Limits{
+	PerUser:    discord.Bucket{Burst: 3, Every: time.Minute},
+	PerCommand: discord.Bucket{Burst: 20, Every: 3 * time.Second},
+}
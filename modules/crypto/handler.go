@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
 )
 
 // TrackingEntry represents a user's cryptocurrency tracking request
@@ -25,31 +27,27 @@ var (
 	trackingMutex sync.RWMutex
 )
 
-func RegisterCryptoHandler(session *discordgo.Session) {
-	session.AddHandler(TrackHandler)
+func RegisterCryptoHandler(r *discord.Router) {
+	// fetching the price may take longer than Discord waits for a reply
+	r.Command(CryptoCommand[0], TrackHandler, discord.RateLimit(CryptoLimits), discord.Defer(false))
+	r.Component(CryptoCommand[0], "stop_tracking_", StopTrackingHandler)
 }
 
 // TrackHandler handles the /track command
-func TrackHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func TrackHandler(s discord.Client, i *discordgo.InteractionCreate) {
 	if !isCryptoCommand(i) {
 		return
 	}
 
-	// Defer the response first to avoid interaction timeout
-	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-	}); err != nil {
-		log.Printf("Failed to acknowledge interaction: %v", err)
-		return
-	}
-
 	// Get the symbol from command options
-	options := i.ApplicationCommandData().Options
-	symbol := strings.ToUpper(options[0].StringValue())
+	var symbol string
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		symbol = strings.ToUpper(strings.TrimSpace(options[0].StringValue()))
+	}
 
 	// Validate symbol
 	if symbol == "" {
-		sendFollowupError(s, i, "❌ Please provide a valid cryptocurrency symbol (e.g., BTC, ETH)")
+		discord.FollowupEphemeral(s, i, "❌ Please provide a valid cryptocurrency symbol (e.g., BTC, ETH)")
 		return
 	}
 
@@ -57,12 +55,12 @@ func TrackHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	price, err := getCryptoPrice(symbol)
 	if err != nil {
 		log.Printf("Error fetching price for %s: %v", symbol, err)
-		sendFollowupError(s, i, fmt.Sprintf("❌ Error fetching price for %s: %s", symbol, err.Error()))
+		discord.FollowupEphemeral(s, i, fmt.Sprintf("❌ Error fetching price for %s: %s", symbol, err.Error()))
 		return
 	}
 
 	// Create initial response message
-	embed := createPriceEmbed(symbol, price, getUser(i))
+	embed := createPriceEmbed(symbol, price, discord.User(i))
 
 	// Send followup message with the embed
 	msg, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
@@ -86,9 +84,9 @@ func TrackHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	// Store tracking entry
 	trackingMutex.Lock()
-	trackingKey := fmt.Sprintf("%s_%s", getUserID(i), symbol)
+	trackingKey := fmt.Sprintf("%s_%s", discord.UserID(i), symbol)
 	trackingMap[trackingKey] = &TrackingEntry{
-		UserID:    getUserID(i),
+		UserID:    discord.UserID(i),
 		Symbol:    symbol,
 		ChannelID: msg.ChannelID,
 		MessageID: msg.ID,
@@ -96,11 +94,11 @@ func TrackHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 	trackingMutex.Unlock()
 
-	log.Printf("Started tracking %s for user %s", symbol, getUser(i).Username)
+	log.Printf("Started tracking %s for user %s", symbol, discord.User(i).Username)
 }
 
 // StopTrackingHandler handles the stop tracking button
-func StopTrackingHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func StopTrackingHandler(s discord.Client, i *discordgo.InteractionCreate) {
 	if i.MessageComponentData().CustomID == "" {
 		return
 	}
@@ -112,7 +110,7 @@ func StopTrackingHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 
 	symbol := strings.TrimPrefix(customID, "stop_tracking_")
-	trackingKey := fmt.Sprintf("%s_%s", getUserID(i), symbol)
+	trackingKey := fmt.Sprintf("%s_%s", discord.UserID(i), symbol)
 
 	// Remove from tracking
 	trackingMutex.Lock()
@@ -122,7 +120,7 @@ func StopTrackingHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	// Update message to show tracking stopped
 	embed := &discordgo.MessageEmbed{
 		Title:       fmt.Sprintf("❌ Stopped Tracking %s", symbol),
-		Description: fmt.Sprintf("No longer tracking %s for <@%s>", symbol, getUserID(i)),
+		Description: fmt.Sprintf("No longer tracking %s for <@%s>", symbol, discord.UserID(i)),
 		Color:       0xff0000, // Red
 		Timestamp:   time.Now().Format(time.RFC3339),
 	}
@@ -137,11 +135,11 @@ func StopTrackingHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	log.Printf("Stopped tracking %s for user %s", symbol, getUser(i).Username)
+	log.Printf("Stopped tracking %s for user %s", symbol, discord.User(i).Username)
 }
 
 // UpdateTrackedPrices periodically updates all tracked cryptocurrency prices
-func UpdateTrackedPrices(s *discordgo.Session) {
+func UpdateTrackedPrices(s discord.Client) {
 	ticker := time.NewTicker(5 * time.Minute) // Update every 5 minutes
 	defer ticker.Stop()
 
@@ -161,7 +159,7 @@ func UpdateTrackedPrices(s *discordgo.Session) {
 }
 
 // updateSinglePrice updates the price for a single tracking entry
-func updateSinglePrice(s *discordgo.Session, entry *TrackingEntry) {
+func updateSinglePrice(s discord.Client, entry *TrackingEntry) {
 	currentPrice, err := getCryptoPrice(entry.Symbol)
 	if err != nil {
 		log.Printf("Error updating price for %s: %v", entry.Symbol, err)
@@ -252,34 +250,6 @@ func isCryptoCommand(i *discordgo.InteractionCreate) bool {
 		i.ApplicationCommandData().Name == "track"
 }
 
-// Helper functions matching your reference pattern
-func getUser(i *discordgo.InteractionCreate) *discordgo.User {
-	if i.Member != nil && i.Member.User != nil {
-		return i.Member.User
-	}
-	if i.User != nil {
-		return i.User
-	}
-	return &discordgo.User{Username: "Unknown"}
-}
-
-func getUserID(i *discordgo.InteractionCreate) string {
-	if i.Member != nil && i.Member.User != nil {
-		return i.Member.User.ID
-	}
-	if i.User != nil {
-		return i.User.ID
-	}
-	return ""
-}
-
-func sendFollowupError(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
-	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: content,
-		Flags:   discordgo.MessageFlagsEphemeral,
-	})
-}
-
 // abs returns the absolute value of a float64
 func abs(x float64) float64 {
 	if x < 0 {
@@ -8,6 +8,9 @@ import (
 	"strings"
 )
 
+// PriceAPI is the base URL of the CoinGecko API
+var PriceAPI = "https://api.coingecko.com/api/v3"
+
 // getCryptoPrice fetches the current price of a cryptocurrency
 func getCryptoPrice(symbol string) (float64, error) {
 	// Convert symbol to lowercase for API call
@@ -21,7 +24,7 @@ func getCryptoPrice(symbol string) (float64, error) {
 		return 0, fmt.Errorf("unsupported cryptocurrency symbol: %s", symbol)
 	}
 
-	url := fmt.Sprintf("https://api.coingecko.com/api/v3/coins/markets?vs_currency=usd&ids=%s", coinID)
+	url := fmt.Sprintf("%s/coins/markets?vs_currency=usd&ids=%s", PriceAPI, coinID)
 
 	client := &http.Client{}
 	req, err := http.NewRequest("GET", url, nil)
@@ -0,0 +1,136 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Owners are the user IDs allowed to run owner-only commands. They pass
+// every other access check as well
+var Owners []string
+
+// Access declares who may run a command. The zero value lets everyone in
+type Access struct {
+	// Permissions are the Discord permission bits the member needs
+	Permissions int64 `json:"permissions"`
+	// Roles, when set, limits the command to members with one of these roles.
+	// Administrators are not limited by it
+	Roles []string `json:"roles,omitempty"`
+	// OwnerOnly limits the command to Owners
+	OwnerOnly bool `json:"owner_only,omitempty"`
+}
+
+// AccessPolicy adjusts the declared access of a command for a guild, e.g.
+// from settings made by the guild's admins
+type AccessPolicy interface {
+	Access(guildID, command string, declared Access) Access
+}
+
+// IsOwner reports whether userID is one of the bot Owners
+func IsOwner(userID string) bool {
+	return userID != "" && slices.Contains(Owners, userID)
+}
+
+// HasPermissions reports whether the member running the interaction has all
+// of the given permission bits. Outside of guilds nobody has permissions
+func HasPermissions(i *discordgo.InteractionCreate, permissions int64) bool {
+	return i.Member != nil && i.Member.Permissions&permissions == permissions
+}
+
+// Check returns an error with a message for the user when the interaction
+// does not satisfy a
+func (a Access) Check(i *discordgo.InteractionCreate) error {
+	return a.check(UserID(i), i.Member, func(permissions int64) bool {
+		return HasPermissions(i, permissions)
+	})
+}
+
+// CheckReaction is Check for the user adding a reaction. Reaction events
+// carry no permissions, so they are looked up with c when needed
+func (a Access) CheckReaction(c Client, m *discordgo.MessageReactionAdd) error {
+	return a.check(m.UserID, m.Member, func(permissions int64) bool {
+		if m.Member == nil {
+			return false
+		}
+		has, err := c.UserChannelPermissions(m.UserID, m.ChannelID)
+		return err == nil && has&permissions == permissions
+	})
+}
+
+// check runs the access checks for a user, has reports whether they have
+// all of the given permission bits
+func (a Access) check(userID string, member *discordgo.Member, has func(permissions int64) bool) error {
+	if IsOwner(userID) {
+		return nil
+	}
+	if a.OwnerOnly {
+		return errors.New("❌ Only the bot owner can use this command")
+	}
+	if a.Permissions != 0 && !has(a.Permissions) {
+		return fmt.Errorf("❌ You need the %s permission to use this command", PermissionNames(a.Permissions))
+	}
+	if len(a.Roles) > 0 && !has(discordgo.PermissionAdministrator) {
+		if member == nil || !slices.ContainsFunc(member.Roles, func(role string) bool {
+			return slices.Contains(a.Roles, role)
+		}) {
+			return errors.New("❌ This command is limited to certain roles on this server")
+		}
+	}
+	return nil
+}
+
+// DefaultMemberPermissions is the value synced to Discord so the command is
+// only shown to members who may use it, nil when everyone may
+func (a Access) DefaultMemberPermissions() *int64 {
+	switch {
+	case a.OwnerOnly:
+		// only administrators see it, the owner check happens on use
+		var none int64
+		return &none
+	case a.Permissions != 0:
+		permissions := a.Permissions
+		return &permissions
+	default:
+		return nil
+	}
+}
+
+// permissionNames are the permissions commands commonly require
+var permissionNames = []struct {
+	bit  int64
+	name string
+}{
+	{discordgo.PermissionAdministrator, "Administrator"},
+	{discordgo.PermissionManageGuild, "Manage Server"},
+	{discordgo.PermissionManageRoles, "Manage Roles"},
+	{discordgo.PermissionManageChannels, "Manage Channels"},
+	{discordgo.PermissionManageMessages, "Manage Messages"},
+	{discordgo.PermissionModerateMembers, "Timeout Members"},
+	{discordgo.PermissionKickMembers, "Kick Members"},
+	{discordgo.PermissionBanMembers, "Ban Members"},
+	{discordgo.PermissionSendMessages, "Send Messages"},
+	{discordgo.PermissionAttachFiles, "Attach Files"},
+}
+
+// PermissionNames describes permission bits for users, e.g. "Manage Server"
+func PermissionNames(permissions int64) string {
+	if permissions == 0 {
+		return "no"
+	}
+
+	var names []string
+	for _, p := range permissionNames {
+		if permissions&p.bit != 0 {
+			names = append(names, p.name)
+			permissions &^= p.bit
+		}
+	}
+	if permissions != 0 {
+		names = append(names, fmt.Sprintf("0x%x", permissions))
+	}
+	return strings.Join(names, " + ")
+}
@@ -0,0 +1,33 @@
+// Package discord describes the part of the Discord API the bot uses, so
+// modules can run against discordgo or an in-memory recorder.
+package discord
+
+import "github.com/bwmarrin/discordgo"
+
+// Client is the set of Discord calls the modules make
+type Client interface {
+	// BotUserID is the ID of the bot user, empty before the session is ready
+	BotUserID() string
+
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error
+	InteractionResponseEdit(interaction *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error)
+	InteractionResponseDelete(interaction *discordgo.Interaction) error
+	FollowupMessageCreate(interaction *discordgo.Interaction, wait bool, data *discordgo.WebhookParams) (*discordgo.Message, error)
+	FollowupMessageEdit(interaction *discordgo.Interaction, messageID string, data *discordgo.WebhookEdit) (*discordgo.Message, error)
+
+	ChannelMessageSend(channelID, content string) (*discordgo.Message, error)
+	ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error)
+	ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error)
+	ChannelMessageEditComplex(m *discordgo.MessageEdit) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string) error
+
+	MessageReactionAdd(channelID, messageID, emojiID string) error
+	MessageReactionRemove(channelID, messageID, emojiID, userID string) error
+	MessageReactionsRemoveEmoji(channelID, messageID, emojiID string) error
+
+	Channel(channelID string) (*discordgo.Channel, error)
+	User(userID string) (*discordgo.User, error)
+	// UserChannelPermissions computes the permissions of a member in a channel
+	UserChannelPermissions(userID, channelID string) (int64, error)
+}
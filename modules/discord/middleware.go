@@ -0,0 +1,133 @@
+package discord
+
+import (
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Recover turns panics in handlers into an ephemeral error reply with an
+// incident ID, see RecoverInteraction
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(s Client, i *discordgo.InteractionCreate) {
+			defer RecoverInteraction(s, i)
+			next(s, i)
+		}
+	}
+}
+
+// Logging logs every interaction and how long its handler took
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(s Client, i *discordgo.InteractionCreate) {
+			start := time.Now()
+			defer func() {
+				log.Printf("Handled %s in %v", describeInteraction(i), time.Since(start).Round(time.Millisecond))
+			}()
+			next(s, i)
+		}
+	}
+}
+
+// Timing warns about handlers that run longer than slow. Interactions have to
+// be acknowledged within three seconds, so slow handlers should defer
+func Timing(slow time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(s Client, i *discordgo.InteractionCreate) {
+			start := time.Now()
+			next(s, i)
+			if elapsed := time.Since(start); elapsed > slow {
+				log.Printf("Slow handler: %s took %v", describeInteraction(i), elapsed.Round(time.Millisecond))
+			}
+		}
+	}
+}
+
+// Defer acknowledges the interaction before the handler runs. The handler
+// gets a client that turns its replies into edits of the deferred response,
+// so it can respond as usual. Ephemeral replies to a public deferral become
+// ephemeral followups. Handlers that open modals must not be deferred
+func Defer(ephemeral bool) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(s Client, i *discordgo.InteractionCreate) {
+			resp := &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredChannelMessageWithSource}
+			if i.Type == discordgo.InteractionMessageComponent {
+				resp.Type = discordgo.InteractionResponseDeferredMessageUpdate
+			}
+			if ephemeral {
+				resp.Data = &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral}
+			}
+			if err := s.InteractionRespond(i.Interaction, resp); err != nil {
+				log.Printf("Failed to acknowledge %s: %v", describeInteraction(i), err)
+				return
+			}
+			// report panics through the deferred response, Recover can only
+			// answer the interaction, which fails once it is acknowledged
+			d := deferredClient{s, i.ID, ephemeral}
+			defer RecoverInteraction(d, i)
+			next(d, i)
+		}
+	}
+}
+
+// deferredClient answers responses to an already deferred interaction by
+// editing the deferred response
+type deferredClient struct {
+	Client
+	interactionID string
+	ephemeral     bool // whether the deferred response is only visible to the user
+}
+
+func (d deferredClient) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error {
+	if interaction.ID != d.interactionID {
+		return d.Client.InteractionRespond(interaction, resp)
+	}
+
+	switch resp.Type {
+	case discordgo.InteractionResponseDeferredChannelMessageWithSource, discordgo.InteractionResponseDeferredMessageUpdate:
+		return nil
+	case discordgo.InteractionResponseChannelMessageWithSource, discordgo.InteractionResponseUpdateMessage:
+		if data := resp.Data; data != nil && data.Flags&discordgo.MessageFlagsEphemeral != 0 && !d.ephemeral {
+			return d.followupEphemeral(interaction, data)
+		}
+		edit := &discordgo.WebhookEdit{}
+		if data := resp.Data; data != nil {
+			if data.Content != "" {
+				edit.Content = &data.Content
+			}
+			if data.Embeds != nil {
+				edit.Embeds = &data.Embeds
+			}
+			if data.Components != nil {
+				edit.Components = &data.Components
+			}
+			edit.Files = data.Files
+		}
+		_, err := d.Client.InteractionResponseEdit(interaction, edit)
+		return err
+	default:
+		return d.Client.InteractionRespond(interaction, resp)
+	}
+}
+
+// followupEphemeral sends an ephemeral reply to a publicly deferred
+// interaction. Editing the deferred response would show the reply to
+// everyone, and Discord ignores the ephemeral flag of the first followup while
+// the "thinking..." message of a command exists, so that message is removed.
+func (d deferredClient) followupEphemeral(interaction *discordgo.Interaction, data *discordgo.InteractionResponseData) error {
+	if interaction.Type == discordgo.InteractionApplicationCommand {
+		if err := d.Client.InteractionResponseDelete(interaction); err != nil {
+			return err
+		}
+	}
+	_, err := d.Client.FollowupMessageCreate(interaction, true, &discordgo.WebhookParams{
+		Content:    data.Content,
+		Embeds:     data.Embeds,
+		Components: data.Components,
+		Files:      data.Files,
+		Flags:      data.Flags,
+	})
+	return err
+}
@@ -0,0 +1,98 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func testInteraction(typ discordgo.InteractionType) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:   "i1",
+		Type: typ,
+		User: &discordgo.User{ID: "u1"},
+	}}
+}
+
+func TestDeferEditsTheDeferredResponse(t *testing.T) {
+	r := NewRecorder()
+	Defer(false)(func(s Client, i *discordgo.InteractionCreate) {
+		Respond(s, i, "done")
+	})(r, testInteraction(discordgo.InteractionApplicationCommand))
+
+	calls := r.Calls()
+	if len(calls) != 2 || calls[0].Method != "InteractionRespond" || calls[1].Method != "InteractionResponseEdit" {
+		t.Fatalf("calls = %+v, want an acknowledgement and an edit", calls)
+	}
+	if edit := calls[1].Args[1].(*discordgo.WebhookEdit); *edit.Content != "done" {
+		t.Errorf("edited content = %q", *edit.Content)
+	}
+}
+
+func TestDeferKeepsEphemeralRepliesPrivate(t *testing.T) {
+	for _, tt := range []struct {
+		typ     discordgo.InteractionType
+		methods []string
+	}{
+		{discordgo.InteractionApplicationCommand, []string{"InteractionRespond", "InteractionResponseDelete", "FollowupMessageCreate"}},
+		{discordgo.InteractionMessageComponent, []string{"InteractionRespond", "FollowupMessageCreate"}},
+	} {
+		r := NewRecorder()
+		Defer(false)(func(s Client, i *discordgo.InteractionCreate) {
+			RespondEphemeral(s, i, "only for you")
+		})(r, testInteraction(tt.typ))
+
+		calls := r.Calls()
+		if len(calls) != len(tt.methods) {
+			t.Fatalf("%v: calls = %+v, want %v", tt.typ, calls, tt.methods)
+		}
+		for n, method := range tt.methods {
+			if calls[n].Method != method {
+				t.Errorf("%v: call %d is %s, want %s", tt.typ, n, calls[n].Method, method)
+			}
+		}
+		params := calls[len(calls)-1].Args[2].(*discordgo.WebhookParams)
+		if params.Content != "only for you" || params.Flags&discordgo.MessageFlagsEphemeral == 0 {
+			t.Errorf("%v: followup %q with flags %d, want it ephemeral", tt.typ, params.Content, params.Flags)
+		}
+	}
+}
+
+func TestEphemeralDeferEditsEphemeralReplies(t *testing.T) {
+	r := NewRecorder()
+	Defer(true)(func(s Client, i *discordgo.InteractionCreate) {
+		RespondEphemeral(s, i, "only for you")
+	})(r, testInteraction(discordgo.InteractionApplicationCommand))
+
+	if calls := r.Calls("InteractionResponseEdit"); len(calls) != 1 {
+		t.Errorf("got %d edits of the ephemeral deferred response, want 1", len(calls))
+	}
+	if calls := r.Calls("FollowupMessageCreate", "InteractionResponseDelete"); len(calls) > 0 {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+}
+
+func TestDeferReportsPanicsPrivately(t *testing.T) {
+	r := NewRecorder()
+	i := testInteraction(discordgo.InteractionApplicationCommand)
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: "crash"}
+	Chain(func(s Client, i *discordgo.InteractionCreate) {
+		panic("boom")
+	}, Recover(), Defer(false))(r, i)
+
+	methods := []string{"InteractionRespond", "InteractionResponseDelete", "FollowupMessageCreate"}
+	calls := r.Calls()
+	if len(calls) != len(methods) {
+		t.Fatalf("calls = %+v, want %v", calls, methods)
+	}
+	for n, method := range methods {
+		if calls[n].Method != method {
+			t.Errorf("call %d is %s, want %s", n, calls[n].Method, method)
+		}
+	}
+	params := calls[2].Args[2].(*discordgo.WebhookParams)
+	if !strings.Contains(params.Content, "incident") || params.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("followup %q with flags %d, want an ephemeral incident report", params.Content, params.Flags)
+	}
+}
@@ -0,0 +1,181 @@
+package discord
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Bucket is a token bucket holding up to Burst tokens, one of which is
+// refilled every Every. The zero value does not limit
+type Bucket struct {
+	Burst int
+	Every time.Duration
+}
+
+func (b Bucket) enabled() bool {
+	return b.Burst > 0 && b.Every > 0
+}
+
+// Limits declares how often a command may run
+type Limits struct {
+	PerUser    Bucket // for each user
+	PerGuild   Bucket // for each guild, not applied in DMs
+	PerCommand Bucket // for everyone together
+	// Concurrent caps how many handlers run at the same time, 0 does not cap
+	Concurrent int
+}
+
+// tokens is the state of one bucket
+type tokens struct {
+	bucket    Bucket
+	available float64
+	updated   time.Time
+}
+
+// limiter keeps the buckets of one Limits declaration
+type limiter struct {
+	limits  Limits
+	mu      sync.Mutex
+	buckets map[string]*tokens
+	running chan struct{}
+}
+
+func newLimiter(limits Limits) *limiter {
+	l := &limiter{limits: limits, buckets: make(map[string]*tokens)}
+	if limits.Concurrent > 0 {
+		l.running = make(chan struct{}, limits.Concurrent)
+	}
+	return l
+}
+
+// refill returns the bucket for key with its tokens brought up to date
+func (l *limiter) refill(key string, b Bucket, now time.Time) *tokens {
+	t, ok := l.buckets[key]
+	if !ok {
+		t = &tokens{bucket: b, available: float64(b.Burst), updated: now}
+		l.buckets[key] = t
+	}
+	t.available = min(float64(b.Burst), t.available+now.Sub(t.updated).Seconds()/b.Every.Seconds())
+	t.updated = now
+	return t
+}
+
+// take consumes a token from every applicable bucket, or none of them when
+// one is empty, and then reports how long to wait for the next token
+func (l *limiter) take(i *discordgo.InteractionCreate, now time.Time) (time.Duration, bool) {
+	type check struct {
+		key    string
+		bucket Bucket
+	}
+	var checks []check
+	if l.limits.PerUser.enabled() {
+		checks = append(checks, check{"user:" + UserID(i), l.limits.PerUser})
+	}
+	if l.limits.PerGuild.enabled() && i.GuildID != "" {
+		checks = append(checks, check{"guild:" + i.GuildID, l.limits.PerGuild})
+	}
+	if l.limits.PerCommand.enabled() {
+		checks = append(checks, check{"command", l.limits.PerCommand})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	state := make([]*tokens, len(checks))
+	for n, c := range checks {
+		state[n] = l.refill(c.key, c.bucket, now)
+		if state[n].available < 1 {
+			missing := time.Duration((1 - state[n].available) * float64(c.bucket.Every))
+			wait = max(wait, missing)
+		}
+	}
+	if wait > 0 {
+		return wait, false
+	}
+	for _, t := range state {
+		t.available--
+	}
+
+	// full buckets carry no information, drop them so the map stays small
+	if len(l.buckets) > 1000 {
+		for key, t := range l.buckets {
+			if l.refill(key, t.bucket, now).available >= float64(t.bucket.Burst) {
+				delete(l.buckets, key)
+			}
+		}
+	}
+	return 0, true
+}
+
+// run calls next if the interaction is within the limits. The concurrency
+// slot is taken first, so interactions turned away because too many are
+// running keep their tokens
+func (l *limiter) run(s Client, i *discordgo.InteractionCreate, next HandlerFunc) {
+	if l.running != nil {
+		select {
+		case l.running <- struct{}{}:
+			defer func() { <-l.running }()
+		default:
+			RespondEphemeral(s, i, "⏳ Too many of these are running right now, try again in a few seconds")
+			return
+		}
+	}
+
+	if wait, ok := l.take(i, time.Now()); !ok {
+		RespondEphemeral(s, i, fmt.Sprintf("⏳ You're doing that too often, try again in %ds", int(math.Ceil(wait.Seconds()))))
+		return
+	}
+	next(s, i)
+}
+
+// RateLimit rejects interactions exceeding limits with an ephemeral reply
+// telling the user when to try again
+func RateLimit(limits Limits) Middleware {
+	l := newLimiter(limits)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(s Client, i *discordgo.InteractionCreate) {
+			l.run(s, i, next)
+		}
+	}
+}
+
+// RateLimitSubcommands is RateLimit with separate limits for each
+// subcommand, keyed by the name of the first option. Subcommands without an
+// entry use the limits under the empty name, subcommands declaring equal
+// limits share their buckets and concurrency cap
+func RateLimitSubcommands(limits map[string]Limits) Middleware {
+	return RateLimitNamed(limits, SubcommandName)
+}
+
+// RateLimitNamed is RateLimitSubcommands with the limits of an interaction
+// picked by name, so a command and the components on its messages can share
+// them by adding the returned middleware to each route
+func RateLimitNamed(limits map[string]Limits, name func(i *discordgo.InteractionCreate) string) Middleware {
+	shared := make(map[Limits]*limiter)
+	limiters := make(map[string]*limiter, len(limits)+1)
+	for name, l := range limits {
+		if shared[l] == nil {
+			shared[l] = newLimiter(l)
+		}
+		limiters[name] = shared[l]
+	}
+	if limiters[""] == nil {
+		limiters[""] = newLimiter(Limits{})
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(s Client, i *discordgo.InteractionCreate) {
+			l := limiters[name(i)]
+			if l == nil {
+				l = limiters[""]
+			}
+			l.run(s, i, next)
+		}
+	}
+}
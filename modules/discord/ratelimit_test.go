@@ -0,0 +1,130 @@
+package discord
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func subcommandInteraction(userID, name string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:   "i-" + name,
+		Type: discordgo.InteractionApplicationCommand,
+		User: &discordgo.User{ID: userID},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "command",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: name, Type: discordgo.ApplicationCommandOptionSubCommand},
+			},
+		},
+	}}
+}
+
+// blocking returns a handler that runs until release is called
+func blocking() (h HandlerFunc, started *sync.WaitGroup, release func()) {
+	started = &sync.WaitGroup{}
+	done := make(chan struct{})
+	h = func(Client, *discordgo.InteractionCreate) {
+		started.Done()
+		<-done
+	}
+	return h, started, func() { close(done) }
+}
+
+// lastReply returns the content of the last interaction response
+func lastReply(r *Recorder) string {
+	calls := r.Calls("InteractionRespond")
+	if len(calls) == 0 {
+		return ""
+	}
+	return calls[len(calls)-1].Args[1].(*discordgo.InteractionResponse).Data.Content
+}
+
+func TestRateLimitRejectsWithoutToken(t *testing.T) {
+	r := NewRecorder()
+	handled := 0
+	h := RateLimit(Limits{PerUser: Bucket{Burst: 1, Every: time.Hour}})(func(Client, *discordgo.InteractionCreate) { handled++ })
+
+	h(r, subcommandInteraction("u1", "a"))
+	h(r, subcommandInteraction("u1", "a"))
+	if handled != 1 || !strings.Contains(lastReply(r), "too often") {
+		t.Errorf("handled %d times, last reply %q", handled, lastReply(r))
+	}
+	h(r, subcommandInteraction("u2", "a"))
+	if handled != 2 {
+		t.Error("another user was limited")
+	}
+}
+
+func TestRateLimitKeepsTokensWhenBusy(t *testing.T) {
+	r := NewRecorder()
+	block, started, release := blocking()
+	mw := RateLimit(Limits{PerUser: Bucket{Burst: 2, Every: time.Hour}, Concurrent: 1})
+	busy := mw(block)
+	handled := 0
+	quick := mw(func(Client, *discordgo.InteractionCreate) { handled++ })
+
+	started.Add(1)
+	go busy(r, subcommandInteraction("u1", "a"))
+	started.Wait()
+
+	// u2 is turned away because u1 is running, which must not cost a token
+	quick(r, subcommandInteraction("u2", "a"))
+	if !strings.Contains(lastReply(r), "Too many") {
+		t.Fatalf("reply %q, want the concurrency message", lastReply(r))
+	}
+	release()
+
+	for n := 0; n < 2; n++ {
+		waitForSlot(t, func() { quick(r, subcommandInteraction("u2", "a")) }, &handled, n+1)
+	}
+}
+
+// waitForSlot retries call until the handler ran want times, the blocked
+// handler may still be returning its slot
+func waitForSlot(t *testing.T, call func(), handled *int, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for *handled < want && time.Now().Before(deadline) {
+		call()
+		if *handled < want {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if *handled < want {
+		t.Fatalf("handled %d times, want %d", *handled, want)
+	}
+}
+
+func TestRateLimitSubcommands(t *testing.T) {
+	download := Limits{Concurrent: 1}
+	mw := RateLimitSubcommands(map[string]Limits{
+		"info":   download,
+		"random": download,
+	})
+	r := NewRecorder()
+	block, started, release := blocking()
+	defer release()
+
+	started.Add(1)
+	go mw(block)(r, subcommandInteraction("u1", "info"))
+	started.Wait()
+
+	handled := 0
+	h := mw(func(Client, *discordgo.InteractionCreate) { handled++ })
+
+	// subcommands with equal limits share the cap
+	h(r, subcommandInteraction("u2", "random"))
+	if handled != 0 || !strings.Contains(lastReply(r), "Too many") {
+		t.Errorf("random ran while info held the shared slot, reply %q", lastReply(r))
+	}
+
+	// everything else is not held up by the running download
+	h(r, subcommandInteraction("u2", "blocklist"))
+	if handled != 1 {
+		t.Error("blocklist was limited by the download cap")
+	}
+}
@@ -0,0 +1,253 @@
+package discord
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ErrUnknown is returned by the Recorder for channels and users it does not know
+var ErrUnknown = errors.New("discord: unknown object")
+
+// Call is a single recorded Client call
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// Recorder is an in-memory Client that records every call, for running
+// handlers without a network
+type Recorder struct {
+	BotID    string
+	Channels map[string]*discordgo.Channel
+	Users    map[string]*discordgo.User
+	// Permissions are returned by UserChannelPermissions for the user ID in
+	// every channel
+	Permissions map[string]int64
+	// Errors makes calls to the named methods fail with the given error
+	Errors map[string]error
+
+	mu     sync.Mutex
+	calls  []Call
+	nextID int64
+}
+
+var _ Client = (*Recorder)(nil)
+
+// NewRecorder returns an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		BotID:       "1",
+		Channels:    make(map[string]*discordgo.Channel),
+		Users:       make(map[string]*discordgo.User),
+		Permissions: make(map[string]int64),
+		Errors:      make(map[string]error),
+	}
+}
+
+// Calls returns the recorded calls, optionally only those to the named methods
+func (r *Recorder) Calls(methods ...string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(methods) == 0 {
+		return append([]Call(nil), r.calls...)
+	}
+	var calls []Call
+	for _, c := range r.calls {
+		for _, m := range methods {
+			if c.Method == m {
+				calls = append(calls, c)
+				break
+			}
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func (r *Recorder) record(method string, args ...any) error {
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case *discordgo.InteractionResponse:
+			if v.Data != nil {
+				bufferFiles(v.Data.Files)
+			}
+		case *discordgo.WebhookParams:
+			bufferFiles(v.Files)
+		case *discordgo.WebhookEdit:
+			bufferFiles(v.Files)
+		case *discordgo.MessageSend:
+			bufferFiles(v.Files)
+		case *discordgo.MessageEdit:
+			bufferFiles(v.Files)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+	return r.Errors[method]
+}
+
+// message builds the message Discord would return
+func (r *Recorder) message(channelID, messageID, content string, embeds []*discordgo.MessageEmbed) *discordgo.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if messageID == "" {
+		r.nextID++
+		messageID = strconv.FormatInt(1000+r.nextID, 10)
+	}
+	return &discordgo.Message{
+		ID:        messageID,
+		ChannelID: channelID,
+		Content:   content,
+		Embeds:    embeds,
+		Author:    &discordgo.User{ID: r.BotID, Bot: true},
+	}
+}
+
+// bufferFiles reads uploads during the call like discordgo does, so callers
+// may close their readers afterwards
+func bufferFiles(files []*discordgo.File) {
+	for _, f := range files {
+		if data, err := io.ReadAll(f.Reader); err == nil {
+			f.Reader = bytes.NewReader(data)
+		}
+	}
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+func (r *Recorder) BotUserID() string {
+	return r.BotID
+}
+
+func (r *Recorder) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error {
+	return r.record("InteractionRespond", interaction, resp)
+}
+
+func (r *Recorder) InteractionResponseEdit(interaction *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	if err := r.record("InteractionResponseEdit", interaction, edit); err != nil {
+		return nil, err
+	}
+	return r.message(interaction.ChannelID, "", deref(edit.Content), deref(edit.Embeds)), nil
+}
+
+func (r *Recorder) InteractionResponseDelete(interaction *discordgo.Interaction) error {
+	return r.record("InteractionResponseDelete", interaction)
+}
+
+func (r *Recorder) FollowupMessageCreate(interaction *discordgo.Interaction, wait bool, data *discordgo.WebhookParams) (*discordgo.Message, error) {
+	if err := r.record("FollowupMessageCreate", interaction, wait, data); err != nil {
+		return nil, err
+	}
+	return r.message(interaction.ChannelID, "", data.Content, data.Embeds), nil
+}
+
+func (r *Recorder) FollowupMessageEdit(interaction *discordgo.Interaction, messageID string, data *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	if err := r.record("FollowupMessageEdit", interaction, messageID, data); err != nil {
+		return nil, err
+	}
+	return r.message(interaction.ChannelID, messageID, deref(data.Content), deref(data.Embeds)), nil
+}
+
+func (r *Recorder) ChannelMessageSend(channelID, content string) (*discordgo.Message, error) {
+	if err := r.record("ChannelMessageSend", channelID, content); err != nil {
+		return nil, err
+	}
+	return r.message(channelID, "", content, nil), nil
+}
+
+func (r *Recorder) ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	if err := r.record("ChannelMessageSendEmbed", channelID, embed); err != nil {
+		return nil, err
+	}
+	return r.message(channelID, "", "", []*discordgo.MessageEmbed{embed}), nil
+}
+
+func (r *Recorder) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	if err := r.record("ChannelMessageSendComplex", channelID, data); err != nil {
+		return nil, err
+	}
+	return r.message(channelID, "", data.Content, data.Embeds), nil
+}
+
+func (r *Recorder) ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	if err := r.record("ChannelMessageEditEmbed", channelID, messageID, embed); err != nil {
+		return nil, err
+	}
+	return r.message(channelID, messageID, "", []*discordgo.MessageEmbed{embed}), nil
+}
+
+func (r *Recorder) ChannelMessageEditComplex(m *discordgo.MessageEdit) (*discordgo.Message, error) {
+	if err := r.record("ChannelMessageEditComplex", m); err != nil {
+		return nil, err
+	}
+	return r.message(m.Channel, m.ID, deref(m.Content), deref(m.Embeds)), nil
+}
+
+func (r *Recorder) ChannelMessageDelete(channelID, messageID string) error {
+	return r.record("ChannelMessageDelete", channelID, messageID)
+}
+
+func (r *Recorder) MessageReactionAdd(channelID, messageID, emojiID string) error {
+	return r.record("MessageReactionAdd", channelID, messageID, emojiID)
+}
+
+func (r *Recorder) MessageReactionRemove(channelID, messageID, emojiID, userID string) error {
+	return r.record("MessageReactionRemove", channelID, messageID, emojiID, userID)
+}
+
+func (r *Recorder) MessageReactionsRemoveEmoji(channelID, messageID, emojiID string) error {
+	return r.record("MessageReactionsRemoveEmoji", channelID, messageID, emojiID)
+}
+
+func (r *Recorder) Channel(channelID string) (*discordgo.Channel, error) {
+	if err := r.record("Channel", channelID); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if channel, ok := r.Channels[channelID]; ok {
+		return channel, nil
+	}
+	return nil, ErrUnknown
+}
+
+func (r *Recorder) User(userID string) (*discordgo.User, error) {
+	if err := r.record("User", userID); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.Users[userID]; ok {
+		return user, nil
+	}
+	return nil, ErrUnknown
+}
+
+func (r *Recorder) UserChannelPermissions(userID, channelID string) (int64, error) {
+	if err := r.record("UserChannelPermissions", userID, channelID); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Permissions[userID], nil
+}
@@ -0,0 +1,95 @@
+package discord
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newIncidentID returns a short ID that ties an error reply to its log entry
+func newIncidentID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// describeInteraction summarizes an interaction for the logs
+func describeInteraction(i *discordgo.InteractionCreate) string {
+	if i == nil || i.Interaction == nil {
+		return "interaction <nil>"
+	}
+
+	var what string
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
+		what = "command /" + i.ApplicationCommandData().Name
+	case discordgo.InteractionMessageComponent:
+		what = "component " + i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		what = "modal " + i.ModalSubmitData().CustomID
+	default:
+		what = fmt.Sprintf("interaction type %d", i.Type)
+	}
+
+	user := ""
+	if i.Member != nil && i.Member.User != nil {
+		user = i.Member.User.ID
+	} else if i.User != nil {
+		user = i.User.ID
+	}
+	return fmt.Sprintf("%s (id %s, user %s, guild %s, channel %s)", what, i.ID, user, i.GuildID, i.ChannelID)
+}
+
+// RecoverInteraction turns a panic in an interaction handler into a log entry
+// with stack trace and an ephemeral error reply carrying the incident ID.
+// It must be deferred directly.
+func RecoverInteraction(s Client, i *discordgo.InteractionCreate) {
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	id := newIncidentID()
+	log.Printf("Incident %s: panic handling %s: %v\n%s", id, describeInteraction(i), p, debug.Stack())
+
+	if i == nil || i.Interaction == nil {
+		return
+	}
+	content := fmt.Sprintf("❌ Something went wrong while handling this. Please report incident `%s` if it keeps happening", id)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		// the handler already acknowledged the interaction
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}); err != nil {
+			log.Printf("Incident %s: failed to notify the user: %v", id, err)
+		}
+	}
+}
+
+// RecoverReaction logs a panic in a reaction handler with stack trace. It
+// must be deferred directly.
+func RecoverReaction(r *discordgo.MessageReactionAdd) {
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	context := "reaction <nil>"
+	if r != nil && r.MessageReaction != nil {
+		context = fmt.Sprintf("reaction %s (user %s, message %s, guild %s, channel %s)",
+			r.Emoji.Name, r.UserID, r.MessageID, r.GuildID, r.ChannelID)
+	}
+	log.Printf("Incident %s: panic handling %s: %v\n%s", newIncidentID(), context, p, debug.Stack())
+}
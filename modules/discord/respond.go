@@ -0,0 +1,86 @@
+package discord
+
+import "github.com/bwmarrin/discordgo"
+
+// Acknowledge defers the reply to an interaction, showing "thinking..."
+func Acknowledge(s Client, i *discordgo.InteractionCreate) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+}
+
+// AcknowledgeEphemeral defers the reply to an interaction like Acknowledge,
+// the reply will only be visible to the user
+func AcknowledgeEphemeral(s Client, i *discordgo.InteractionCreate) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// Respond replies to an interaction with a message everyone can see
+func Respond(s Client, i *discordgo.InteractionCreate, content string) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+// RespondEphemeral replies to an interaction with a message only the user sees
+func RespondEphemeral(s Client, i *discordgo.InteractionCreate, content string) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// FollowupEphemeral sends a message only the user sees after the interaction
+// was acknowledged
+func FollowupEphemeral(s Client, i *discordgo.InteractionCreate, content string) error {
+	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	return err
+}
+
+// UserID returns the ID of the user who triggered the interaction, in guilds and DMs
+func UserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// User returns the user who triggered the interaction, or a placeholder
+func User(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	if i.User != nil {
+		return i.User
+	}
+	return &discordgo.User{Username: "Unknown"}
+}
+
+// SubcommandName returns the name of the first option of a slash command,
+// which is the subcommand or group that was run, or "" for other interactions
+func SubcommandName(i *discordgo.InteractionCreate) string {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return ""
+	}
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		return options[0].Name
+	}
+	return ""
+}
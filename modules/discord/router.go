@@ -0,0 +1,245 @@
+package discord
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandlerFunc handles an interaction
+type HandlerFunc func(s Client, i *discordgo.InteractionCreate)
+
+// ReactionFunc handles a reaction being added
+type ReactionFunc func(s Client, r *discordgo.MessageReactionAdd)
+
+// Middleware wraps a handler to add behaviour before or after it runs
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Chain applies middleware to h; the first middleware runs outermost
+func Chain(h HandlerFunc, middleware ...Middleware) HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// Router dispatches interactions to the handler registered for the command
+// name or component custom ID, running the shared and per route middleware
+type Router struct {
+	client     Client
+	middleware []Middleware
+	commands   map[string]HandlerFunc
+	components []componentRoute
+	reactions  []ReactionFunc
+	defs       []*discordgo.ApplicationCommand
+	access     map[string]Access
+	subAccess  map[string]map[string]Access
+	policy     AccessPolicy
+}
+
+type componentRoute struct {
+	prefix  string
+	handler HandlerFunc
+}
+
+// NewRouter returns a router that calls handlers with c
+func NewRouter(c Client) *Router {
+	return &Router{
+		client:    c,
+		commands:  make(map[string]HandlerFunc),
+		access:    make(map[string]Access),
+		subAccess: make(map[string]map[string]Access),
+	}
+}
+
+// Client returns the client handlers are called with
+func (r *Router) Client() Client {
+	return r.client
+}
+
+// Use adds middleware that runs around every interaction handler, including
+// ones registered earlier
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Command registers the handler for a slash command together with its
+// definition, which Commands returns for syncing with Discord. The access
+// declared with Restrict is checked after the middleware added with Use and
+// before the middleware passed here
+func (r *Router) Command(def *discordgo.ApplicationCommand, h HandlerFunc, middleware ...Middleware) {
+	r.commands[def.Name] = Chain(h, append([]Middleware{r.authorize(def.Name)}, middleware...)...)
+	r.defs = append(r.defs, def)
+}
+
+// Restrict declares who may run the command
+func (r *Router) Restrict(def *discordgo.ApplicationCommand, access Access) {
+	r.access[def.Name] = access
+}
+
+// RestrictSubcommands declares who may run subcommands of the command, keyed
+// by the name of the first option. It replaces the access declared with
+// Restrict for those subcommands, the policy of the command applies to both
+func (r *Router) RestrictSubcommands(def *discordgo.ApplicationCommand, access map[string]Access) {
+	r.subAccess[def.Name] = access
+}
+
+// RestrictedSubcommands returns the names of the subcommands of command that
+// declare their own access, sorted
+func (r *Router) RestrictedSubcommands(command string) []string {
+	names := make([]string, 0, len(r.subAccess[command]))
+	for name := range r.subAccess[command] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SetPolicy lets p adjust the declared access per guild
+func (r *Router) SetPolicy(p AccessPolicy) {
+	r.policy = p
+}
+
+// Access returns the declared access of a command adjusted for guildID
+func (r *Router) Access(guildID, command string) Access {
+	return r.adjust(guildID, command, r.access[command])
+}
+
+// SubcommandAccess returns the access of a subcommand adjusted for guildID,
+// which is the access of the command unless the subcommand declares its own
+func (r *Router) SubcommandAccess(guildID, command, subcommand string) Access {
+	access, ok := r.subAccess[command][subcommand]
+	if !ok {
+		return r.Access(guildID, command)
+	}
+	return r.adjust(guildID, command, access)
+}
+
+// adjust applies the policy for command in guildID to access
+func (r *Router) adjust(guildID, command string, access Access) Access {
+	if r.policy != nil && guildID != "" {
+		access = r.policy.Access(guildID, command, access)
+	}
+	return access
+}
+
+// authorize rejects users who may not run the command
+func (r *Router) authorize(command string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(s Client, i *discordgo.InteractionCreate) {
+			access := r.Access(i.GuildID, command)
+			if sub := SubcommandName(i); sub != "" {
+				access = r.SubcommandAccess(i.GuildID, command, sub)
+			}
+			if err := access.Check(i); err != nil {
+				RespondEphemeral(s, i, err.Error())
+				return
+			}
+			next(s, i)
+		}
+	}
+}
+
+// Component registers the handler for message components and modals whose
+// custom ID starts with prefix. They are subject to the access of command,
+// the command whose messages they belong to, checked like in Command. A nil
+// command leaves them open to everyone
+func (r *Router) Component(command *discordgo.ApplicationCommand, prefix string, h HandlerFunc, middleware ...Middleware) {
+	if command != nil {
+		middleware = append([]Middleware{r.authorize(command.Name)}, middleware...)
+	}
+	r.components = append(r.components, componentRoute{prefix, Chain(h, middleware...)})
+}
+
+// Reaction registers a handler for added reactions
+func (r *Router) Reaction(h ReactionFunc) {
+	r.reactions = append(r.reactions, h)
+}
+
+// ReactionFor registers a handler for added reactions that is only called
+// for users who may run command, like the components registered with it.
+// Reactions can not be answered, so the others are ignored
+func (r *Router) ReactionFor(command *discordgo.ApplicationCommand, h ReactionFunc) {
+	r.Reaction(func(s Client, m *discordgo.MessageReactionAdd) {
+		if err := r.Access(m.GuildID, command.Name).CheckReaction(s, m); err != nil {
+			log.Printf("Ignored reaction %s of user %s on message %s: %v", m.Emoji.Name, m.UserID, m.MessageID, err)
+			return
+		}
+		h(s, m)
+	})
+}
+
+// Commands returns the definitions of all registered commands
+func (r *Router) Commands() []*discordgo.ApplicationCommand {
+	return r.defs
+}
+
+// CommandsFor returns the command definitions to sync for guildID, or globally
+// when it is empty, with DefaultMemberPermissions reflecting who may use them
+func (r *Router) CommandsFor(guildID string) []*discordgo.ApplicationCommand {
+	defs := make([]*discordgo.ApplicationCommand, 0, len(r.defs))
+	for _, def := range r.defs {
+		synced := *def
+		if permissions := r.Access(guildID, def.Name).DefaultMemberPermissions(); permissions != nil {
+			synced.DefaultMemberPermissions = permissions
+		}
+		defs = append(defs, &synced)
+	}
+	return defs
+}
+
+// Attach makes s deliver its interactions and reactions to the router
+func (r *Router) Attach(s *discordgo.Session) {
+	s.AddHandler(func(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+		r.Dispatch(i)
+	})
+	s.AddHandler(func(_ *discordgo.Session, m *discordgo.MessageReactionAdd) {
+		r.DispatchReaction(m)
+	})
+}
+
+// Dispatch runs the handler matching i, if any
+func (r *Router) Dispatch(i *discordgo.InteractionCreate) {
+	h := r.route(i)
+	if h == nil {
+		return
+	}
+	Chain(h, r.middleware...)(r.client, i)
+}
+
+func (r *Router) route(i *discordgo.InteractionCreate) HandlerFunc {
+	if i == nil || i.Interaction == nil {
+		return nil
+	}
+
+	var customID string
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		return r.commands[i.ApplicationCommandData().Name]
+	case discordgo.InteractionMessageComponent:
+		customID = i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		customID = i.ModalSubmitData().CustomID
+	default:
+		return nil
+	}
+
+	for _, c := range r.components {
+		if strings.HasPrefix(customID, c.prefix) {
+			return c.handler
+		}
+	}
+	return nil
+}
+
+// DispatchReaction runs every reaction handler, recovering panics
+func (r *Router) DispatchReaction(m *discordgo.MessageReactionAdd) {
+	for _, h := range r.reactions {
+		func() {
+			defer RecoverReaction(m)
+			h(r.client, m)
+		}()
+	}
+}
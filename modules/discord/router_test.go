@@ -0,0 +1,113 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func member(permissions int64) *discordgo.Member {
+	return &discordgo.Member{User: &discordgo.User{ID: "u1"}, Permissions: permissions}
+}
+
+func TestComponentsUseTheAccessOfTheirCommand(t *testing.T) {
+	def := &discordgo.ApplicationCommand{Name: "admin"}
+	router := NewRouter(NewRecorder())
+	handled := 0
+	h := func(Client, *discordgo.InteractionCreate) { handled++ }
+	router.Command(def, h)
+	router.Component(def, "admin:", h)
+	router.Component(nil, "open:", h)
+	router.Restrict(def, Access{Permissions: discordgo.PermissionManageGuild})
+
+	interactions := map[string]*discordgo.Interaction{
+		"command":   {Type: discordgo.InteractionApplicationCommand, Data: discordgo.ApplicationCommandInteractionData{Name: "admin"}},
+		"component": {Type: discordgo.InteractionMessageComponent, Data: discordgo.MessageComponentInteractionData{CustomID: "admin:x"}},
+		"modal":     {Type: discordgo.InteractionModalSubmit, Data: discordgo.ModalSubmitInteractionData{CustomID: "admin:y"}},
+	}
+	for name, i := range interactions {
+		r := NewRecorder()
+		router.client = r
+		handled = 0
+
+		i.Member = member(0)
+		router.Dispatch(&discordgo.InteractionCreate{Interaction: i})
+		if handled != 0 {
+			t.Errorf("%s ran without the required permission", name)
+		}
+		calls := r.Calls("InteractionRespond")
+		if len(calls) != 1 || !strings.Contains(calls[0].Args[1].(*discordgo.InteractionResponse).Data.Content, "Manage Server") {
+			t.Errorf("%s: responses %+v, want the permission error", name, calls)
+		}
+
+		i.Member = member(discordgo.PermissionManageGuild)
+		router.Dispatch(&discordgo.InteractionCreate{Interaction: i})
+		if handled != 1 {
+			t.Errorf("%s did not run with the required permission", name)
+		}
+	}
+
+	handled = 0
+	router.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:   discordgo.InteractionMessageComponent,
+		Member: member(0),
+		Data:   discordgo.MessageComponentInteractionData{CustomID: "open:z"},
+	}})
+	if handled != 1 {
+		t.Error("a component without a command was restricted")
+	}
+}
+
+type allowRole string
+
+func (role allowRole) Access(guildID, command string, declared Access) Access {
+	declared.Roles = []string{string(role)}
+	return declared
+}
+
+func TestSubcommandAccess(t *testing.T) {
+	def := &discordgo.ApplicationCommand{Name: "watch"}
+	router := NewRouter(NewRecorder())
+	handled := 0
+	router.Command(def, func(Client, *discordgo.InteractionCreate) { handled++ })
+	router.RestrictSubcommands(def, map[string]Access{"add": {Permissions: discordgo.PermissionManageGuild}})
+
+	run := func(sub string, m *discordgo.Member) bool {
+		handled = 0
+		router.Dispatch(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:    discordgo.InteractionApplicationCommand,
+			GuildID: "g1",
+			Member:  m,
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name:    "watch",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand}},
+			},
+		}})
+		return handled == 1
+	}
+
+	if !run("list", member(0)) {
+		t.Error("an unrestricted subcommand was restricted")
+	}
+	if run("add", member(0)) {
+		t.Error("a restricted subcommand ran without the required permission")
+	}
+	if !run("add", member(discordgo.PermissionManageGuild)) {
+		t.Error("a restricted subcommand did not run with the required permission")
+	}
+	if defs := router.CommandsFor("g1"); defs[0].DefaultMemberPermissions != nil {
+		t.Error("the command is hidden from members who may use some of its subcommands")
+	}
+
+	// the policy of the command applies to its subcommands as well
+	router.SetPolicy(allowRole("r1"))
+	withRole := member(discordgo.PermissionManageGuild)
+	if run("add", withRole) {
+		t.Error("the policy was not applied to the subcommand")
+	}
+	withRole.Roles = []string{"r1"}
+	if !run("add", withRole) {
+		t.Error("a member allowed by the policy was rejected")
+	}
+}
@@ -0,0 +1,103 @@
+package discord
+
+import "github.com/bwmarrin/discordgo"
+
+// Session adapts a discordgo session to Client
+type Session struct {
+	*discordgo.Session
+}
+
+// New wraps s
+func New(s *discordgo.Session) *Session {
+	return &Session{s}
+}
+
+var _ Client = (*Session)(nil)
+
+func (s *Session) BotUserID() string {
+	if s.State == nil || s.State.User == nil {
+		return ""
+	}
+	return s.State.User.ID
+}
+
+func (s *Session) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error {
+	return s.Session.InteractionRespond(interaction, resp)
+}
+
+func (s *Session) InteractionResponseEdit(interaction *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	return s.Session.InteractionResponseEdit(interaction, edit)
+}
+
+func (s *Session) InteractionResponseDelete(interaction *discordgo.Interaction) error {
+	return s.Session.InteractionResponseDelete(interaction)
+}
+
+func (s *Session) FollowupMessageCreate(interaction *discordgo.Interaction, wait bool, data *discordgo.WebhookParams) (*discordgo.Message, error) {
+	return s.Session.FollowupMessageCreate(interaction, wait, data)
+}
+
+func (s *Session) FollowupMessageEdit(interaction *discordgo.Interaction, messageID string, data *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	return s.Session.FollowupMessageEdit(interaction, messageID, data)
+}
+
+func (s *Session) ChannelMessageSend(channelID, content string) (*discordgo.Message, error) {
+	return s.Session.ChannelMessageSend(channelID, content)
+}
+
+func (s *Session) ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	return s.Session.ChannelMessageSendEmbed(channelID, embed)
+}
+
+func (s *Session) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	return s.Session.ChannelMessageSendComplex(channelID, data)
+}
+
+func (s *Session) ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	return s.Session.ChannelMessageEditEmbed(channelID, messageID, embed)
+}
+
+func (s *Session) ChannelMessageEditComplex(m *discordgo.MessageEdit) (*discordgo.Message, error) {
+	return s.Session.ChannelMessageEditComplex(m)
+}
+
+func (s *Session) ChannelMessageDelete(channelID, messageID string) error {
+	return s.Session.ChannelMessageDelete(channelID, messageID)
+}
+
+func (s *Session) MessageReactionAdd(channelID, messageID, emojiID string) error {
+	return s.Session.MessageReactionAdd(channelID, messageID, emojiID)
+}
+
+func (s *Session) MessageReactionRemove(channelID, messageID, emojiID, userID string) error {
+	return s.Session.MessageReactionRemove(channelID, messageID, emojiID, userID)
+}
+
+func (s *Session) MessageReactionsRemoveEmoji(channelID, messageID, emojiID string) error {
+	return s.Session.MessageReactionsRemoveEmoji(channelID, messageID, emojiID)
+}
+
+// Channel looks the channel up in the session state, falling back to the REST API
+func (s *Session) Channel(channelID string) (*discordgo.Channel, error) {
+	if s.State != nil {
+		if channel, err := s.State.Channel(channelID); err == nil {
+			return channel, nil
+		}
+	}
+
+	channel, err := s.Session.Channel(channelID)
+	if err == nil && s.State != nil && s.StateEnabled {
+		s.State.ChannelAdd(channel)
+	}
+	return channel, err
+}
+
+func (s *Session) User(userID string) (*discordgo.User, error) {
+	return s.Session.User(userID)
+}
+
+// UserChannelPermissions computes the permissions from the session state,
+// falling back to the REST API
+func (s *Session) UserChannelPermissions(userID, channelID string) (int64, error) {
+	return s.Session.UserChannelPermissions(userID, channelID)
+}
@@ -0,0 +1,174 @@
+package discordtest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// gatewayPayload is a message sent over the gateway
+type gatewayPayload struct {
+	Op       int    `json:"op"`
+	Data     any    `json:"d"`
+	Sequence int64  `json:"s,omitempty"`
+	Type     string `json:"t,omitempty"`
+}
+
+// serveGateway performs the hello, identify and ready exchange and then
+// acknowledges heartbeats until the session disconnects
+func (srv *Server) serveGateway(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	writeMu := &sync.Mutex{}
+	write := func(p gatewayPayload) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(p)
+	}
+
+	if err := write(gatewayPayload{Op: 10, Data: map[string]int{"heartbeat_interval": 45000}}); err != nil {
+		return
+	}
+
+	var identify gatewayPayload
+	if err := conn.ReadJSON(&identify); err != nil || identify.Op != 2 {
+		return
+	}
+
+	srv.mu.Lock()
+	srv.sequence++
+	ready := gatewayPayload{
+		Op:       0,
+		Type:     "READY",
+		Sequence: srv.sequence,
+		Data: map[string]any{
+			"v":           9,
+			"session_id":  "fake-session",
+			"user":        discordgo.User{ID: BotID, Username: "bot", Bot: true},
+			"guilds":      []any{},
+			"application": map[string]string{"id": AppID},
+		},
+	}
+	srv.conns[conn] = writeMu
+	srv.mu.Unlock()
+
+	defer func() {
+		srv.mu.Lock()
+		delete(srv.conns, conn)
+		srv.mu.Unlock()
+	}()
+
+	if err := write(ready); err != nil {
+		return
+	}
+
+	for {
+		var p gatewayPayload
+		if err := conn.ReadJSON(&p); err != nil {
+			return
+		}
+		if p.Op == 1 {
+			if err := write(gatewayPayload{Op: 11}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Dispatch sends an event of the given type, e.g. INTERACTION_CREATE, to
+// every connected session
+func (srv *Server) Dispatch(eventType string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.conns) == 0 {
+		return errors.New("discordtest: no session is connected")
+	}
+	srv.sequence++
+	p := gatewayPayload{Op: 0, Type: eventType, Sequence: srv.sequence, Data: json.RawMessage(raw)}
+	for conn, mu := range srv.conns {
+		mu.Lock()
+		err := conn.WriteJSON(p)
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InjectInteraction delivers an INTERACTION_CREATE event, filling in the IDs
+// and token the fake needs when they are missing
+func (srv *Server) InjectInteraction(i *discordgo.Interaction) error {
+	if i.ID == "" {
+		i.ID = srv.NewID()
+	}
+	if i.AppID == "" {
+		i.AppID = AppID
+	}
+	if i.Token == "" {
+		i.Token = "token-" + i.ID
+	}
+	return srv.Dispatch("INTERACTION_CREATE", i)
+}
+
+// InjectReactionAdd delivers a MESSAGE_REACTION_ADD event
+func (srv *Server) InjectReactionAdd(r *discordgo.MessageReaction) error {
+	return srv.Dispatch("MESSAGE_REACTION_ADD", r)
+}
+
+// CommandInteraction builds a slash command interaction from user in channel
+func CommandInteraction(name string, user *discordgo.User, channelID string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.Interaction {
+	return &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: channelID,
+		User:      user,
+		Data: discordgo.ApplicationCommandInteractionData{
+			ID:      name,
+			Name:    name,
+			Options: options,
+		},
+	}
+}
+
+// ComponentInteraction builds a button or select menu interaction on message
+func ComponentInteraction(customID string, user *discordgo.User, message *discordgo.Message, values ...string) *discordgo.Interaction {
+	return &discordgo.Interaction{
+		Type:      discordgo.InteractionMessageComponent,
+		ChannelID: message.ChannelID,
+		Message:   message,
+		User:      user,
+		Data: discordgo.MessageComponentInteractionData{
+			CustomID: customID,
+			Values:   values,
+		},
+	}
+}
+
+// Option builds a command option; value decides its type
+func Option(name string, value any) *discordgo.ApplicationCommandInteractionDataOption {
+	option := &discordgo.ApplicationCommandInteractionDataOption{Name: name, Value: value}
+	switch v := value.(type) {
+	case string:
+		option.Type = discordgo.ApplicationCommandOptionString
+	case int:
+		option.Type = discordgo.ApplicationCommandOptionInteger
+		option.Value = float64(v) // how JSON delivers numbers
+	case float64:
+		option.Type = discordgo.ApplicationCommandOptionNumber
+	case bool:
+		option.Type = discordgo.ApplicationCommandOptionBoolean
+	}
+	return option
+}
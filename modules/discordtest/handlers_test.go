@@ -0,0 +1,304 @@
+package discordtest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/crypto"
+	"test/modules/discord"
+	"test/modules/discordtest"
+	"test/modules/doujin"
+	"test/modules/math"
+	"test/modules/storage"
+)
+
+const wait = 5 * time.Second
+
+// start connects a session to a fake server and attaches a router the test
+// registers its modules on
+func start(t *testing.T, register ...func(*discord.Router)) *discordtest.Server {
+	t.Helper()
+	dir := storage.Dir
+	storage.Dir = t.TempDir()
+	t.Cleanup(func() { storage.Dir = dir })
+
+	srv := discordtest.NewServer()
+	s, err := srv.Session()
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.Close()
+		srv.Close()
+	})
+
+	router := discord.NewRouter(discord.New(s))
+	router.Use(discord.Recover())
+	for _, r := range register {
+		r(router)
+	}
+	router.Attach(s)
+	return srv
+}
+
+// inject delivers an interaction and fails the test if that is not possible
+func inject(t *testing.T, srv *discordtest.Server, i *discordgo.Interaction) {
+	t.Helper()
+	if err := srv.InjectInteraction(i); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func user(id string) *discordgo.User {
+	return &discordgo.User{ID: id, Username: "user" + id}
+}
+
+// callbackType waits for the interaction callback and returns its type
+func callbackType(t *testing.T, srv *discordtest.Server) discordgo.InteractionResponseType {
+	t.Helper()
+	req, err := srv.WaitFor(discordtest.InteractionCallback, wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := req.InteractionResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Type
+}
+
+func TestCollatzConjecture(t *testing.T) {
+	srv := start(t, math.RegisterCollatzConjectureHandler)
+
+	inject(t, srv, discordtest.CommandInteraction("collatzconjecture", user("1"), "c1",
+		discordtest.Option("int", "6, 7")))
+
+	if typ := callbackType(t, srv); typ != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Errorf("callback type %d, want a deferred message", typ)
+	}
+	req, err := srv.WaitFor(discordtest.OriginalEdit, wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := req.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Collatz sequence for 6: [6 3 10 5 16 8 4 2 1]", "Collatz sequence for 7: [7 22"} {
+		if !strings.Contains(m.Content, want) {
+			t.Errorf("response %q does not contain %q", m.Content, want)
+		}
+	}
+}
+
+func TestCollatzConjectureRejectsBadInput(t *testing.T) {
+	srv := start(t, math.RegisterCollatzConjectureHandler)
+
+	inject(t, srv, discordtest.CommandInteraction("collatzconjecture", user("1"), "c1",
+		discordtest.Option("int", "1, x")))
+
+	req, err := srv.WaitFor(discordtest.InteractionCallback, wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := req.InteractionResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `unexpected character at position 4 ("x")`; resp.Data == nil || !strings.Contains(resp.Data.Content, want) {
+		t.Errorf("response %+v does not contain %q", resp.Data, want)
+	}
+}
+
+func TestTrack(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/coins/markets" || r.URL.Query().Get("ids") != "bitcoin" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"current_price":65432.1,"symbol":"btc","name":"Bitcoin"}]`))
+	}))
+	defer api.Close()
+	priceAPI := crypto.PriceAPI
+	crypto.PriceAPI = api.URL
+	defer func() { crypto.PriceAPI = priceAPI }()
+
+	srv := start(t, crypto.RegisterCryptoHandler)
+	inject(t, srv, discordtest.CommandInteraction("track", user("2"), "c1", discordtest.Option("symbol", "btc")))
+
+	if typ := callbackType(t, srv); typ != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Errorf("callback type %d, want a deferred message", typ)
+	}
+	req, err := srv.WaitFor(func(r discordtest.Request) bool {
+		return discordtest.Followup(r) || discordtest.OriginalEdit(r)
+	}, wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := req.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Embeds) != 1 || !strings.Contains(m.Embeds[0].Title+m.Embeds[0].Description+fieldText(m.Embeds[0]), "65") {
+		t.Errorf("price embed = %+v", m.Embeds)
+	}
+	if len(m.Components) == 0 {
+		t.Error("price message has no stop button")
+	}
+}
+
+func TestTrackUnsupportedSymbol(t *testing.T) {
+	srv := start(t, crypto.RegisterCryptoHandler)
+	inject(t, srv, discordtest.CommandInteraction("track", user("3"), "c1", discordtest.Option("symbol", "nope")))
+
+	req, err := srv.WaitFor(discordtest.Followup, wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var params discordgo.WebhookParams
+	if err := req.JSON(&params); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(params.Content, "unsupported cryptocurrency symbol") || params.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("got %q with flags %d, want an ephemeral error", params.Content, params.Flags)
+	}
+}
+
+func fieldText(embed *discordgo.MessageEmbed) string {
+	var b strings.Builder
+	for _, f := range embed.Fields {
+		b.WriteString(f.Name + " " + f.Value + "\n")
+	}
+	return b.String()
+}
+
+// fakeNhentai serves one gallery and its images
+func fakeNhentai(t *testing.T) {
+	t.Helper()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/gallery/177013":
+			w.Write([]byte(`{"id":177013,"media_id":"987654","num_pages":2,
+				"images":{"pages":[{"t":"j"},{"t":"j"}]},
+				"title":{"pretty":"Example Gallery"},
+				"tags":[{"type":"tag","name":"example"}]}`))
+		case strings.HasPrefix(r.URL.Path, "/galleries/987654/"):
+			w.Write([]byte("image"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	base, images, thumbs := doujin.DefaultClient.BaseURL, doujin.DefaultClient.ImageBaseURL, doujin.DefaultClient.ThumbBaseURL
+	doujin.DefaultClient.BaseURL = api.URL
+	doujin.DefaultClient.ImageBaseURL = api.URL
+	doujin.DefaultClient.ThumbBaseURL = api.URL
+	cache := doujin.Cache
+	doujin.Cache = doujin.NewPageCache(t.TempDir(), 0)
+	t.Cleanup(func() {
+		doujin.DefaultClient.BaseURL = base
+		doujin.DefaultClient.ImageBaseURL = images
+		doujin.DefaultClient.ThumbBaseURL = thumbs
+		doujin.Cache = cache
+	})
+}
+
+// guildCommand builds a command interaction from a member of guild g1
+func guildCommand(name, userID, channelID string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.Interaction {
+	i := discordtest.CommandInteraction(name, nil, channelID, options...)
+	i.GuildID = "g1"
+	i.Member = &discordgo.Member{User: user(userID), Permissions: discordgo.PermissionViewChannel}
+	return i
+}
+
+func subcommand(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:    name,
+		Type:    discordgo.ApplicationCommandOptionSubCommand,
+		Options: options,
+	}
+}
+
+func TestDoujinInfo(t *testing.T) {
+	fakeNhentai(t)
+	srv := start(t, doujin.RegisterDoujinHandler)
+	srv.Handle(http.MethodGet, "channels/nsfw", func(discordtest.Request) (int, any) {
+		return http.StatusOK, discordgo.Channel{ID: "nsfw", GuildID: "g1", Type: discordgo.ChannelTypeGuildText, NSFW: true}
+	})
+
+	inject(t, srv, guildCommand("doujin", "4", "nsfw", subcommand("info", discordtest.Option("code", "177013"))))
+
+	if typ := callbackType(t, srv); typ != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Errorf("callback type %d, want a deferred message", typ)
+	}
+	req, err := srv.WaitFor(discordtest.Followup, wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := req.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Embeds) == 0 || m.Embeds[0].Title != "Example Gallery" {
+		t.Errorf("info embeds = %+v", m.Embeds)
+	}
+	if _, err := srv.WaitFor(discordtest.ReactionAdd, wait); err != nil {
+		t.Errorf("reader reaction: %v", err)
+	}
+}
+
+func TestDoujinRefusesNonNSFWChannels(t *testing.T) {
+	fakeNhentai(t)
+	srv := start(t, doujin.RegisterDoujinHandler)
+	srv.Handle(http.MethodGet, "channels/general", func(discordtest.Request) (int, any) {
+		return http.StatusOK, discordgo.Channel{ID: "general", GuildID: "g1", Type: discordgo.ChannelTypeGuildText}
+	})
+
+	inject(t, srv, guildCommand("doujin", "5", "general", subcommand("info", discordtest.Option("code", "177013"))))
+
+	req, err := srv.WaitFor(discordtest.InteractionCallback, wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := req.InteractionResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data == nil || !strings.Contains(resp.Data.Content, "NSFW") || resp.Data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("response = %+v, want an ephemeral NSFW refusal", resp.Data)
+	}
+	for _, r := range srv.Requests() {
+		if discordtest.Followup(r) {
+			t.Errorf("sent a followup to a non-NSFW channel: %s", r.Path)
+		}
+	}
+}
+
+func TestDoujinRejectsInvalidCode(t *testing.T) {
+	fakeNhentai(t)
+	srv := start(t, doujin.RegisterDoujinHandler)
+	srv.Handle(http.MethodGet, "channels/nsfw", func(discordtest.Request) (int, any) {
+		return http.StatusOK, discordgo.Channel{ID: "nsfw", GuildID: "g1", Type: discordgo.ChannelTypeGuildText, NSFW: true}
+	})
+
+	inject(t, srv, guildCommand("doujin", "6", "nsfw", subcommand("info", discordtest.Option("code", "../1"))))
+
+	req, err := srv.WaitFor(discordtest.InteractionCallback, wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := req.InteractionResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data == nil || !strings.Contains(resp.Data.Content, "valid code") {
+		t.Errorf("response = %+v, want an invalid code error", resp.Data)
+	}
+}
@@ -0,0 +1,126 @@
+package discordtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Request is a recorded REST call
+type Request struct {
+	Method string
+	Path   string // relative to the API root, e.g. channels/1/messages
+	Query  string
+	Body   []byte // the JSON payload, taken from payload_json for uploads
+	Files  []File
+	Time   time.Time
+}
+
+// File is an attachment uploaded with a request
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+func newRequest(r *http.Request) (Request, error) {
+	req := Request{
+		Method: r.Method,
+		Path:   strings.TrimPrefix(r.URL.Path, apiPath),
+		Query:  r.URL.RawQuery,
+		Time:   time.Now(),
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		body, err := io.ReadAll(r.Body)
+		req.Body = body
+		return req, err
+	}
+
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return req, nil
+		}
+		if err != nil {
+			return req, err
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return req, err
+		}
+		if part.FormName() == "payload_json" {
+			req.Body = data
+			continue
+		}
+		req.Files = append(req.Files, File{
+			Name:        part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Data:        data,
+		})
+	}
+}
+
+// JSON decodes the request payload into v
+func (r Request) JSON(v any) error {
+	if len(bytes.TrimSpace(r.Body)) == 0 {
+		return errors.New("discordtest: request has no body")
+	}
+	return json.Unmarshal(r.Body, v)
+}
+
+// InteractionResponse decodes the payload of an interaction callback
+func (r Request) InteractionResponse() (*discordgo.InteractionResponse, error) {
+	var resp discordgo.InteractionResponse
+	if err := r.JSON(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Message decodes the payload of a message create or edit. It decodes into a
+// discordgo.Message because only that type can unmarshal components.
+func (r Request) Message() (*discordgo.Message, error) {
+	var m discordgo.Message
+	if err := r.JSON(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// InteractionCallback matches the initial response to an interaction
+func InteractionCallback(r Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Path, "interactions/") && strings.HasSuffix(r.Path, "/callback")
+}
+
+// OriginalEdit matches edits of the original interaction response
+func OriginalEdit(r Request) bool {
+	return r.Method == http.MethodPatch && strings.HasPrefix(r.Path, "webhooks/") && strings.HasSuffix(r.Path, "/messages/@original")
+}
+
+// Followup matches followup messages sent for an interaction
+func Followup(r Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Path, "webhooks/") && !strings.Contains(r.Path, "/messages/")
+}
+
+// ReactionAdd matches the bot adding a reaction
+func ReactionAdd(r Request) bool {
+	return r.Method == http.MethodPut && strings.Contains(r.Path, "/reactions/")
+}
+
+// ChannelMessage matches messages sent to or edited in a channel
+func ChannelMessage(r Request) bool {
+	return strings.HasPrefix(r.Path, "channels/") && strings.Contains(r.Path, "/messages") &&
+		!strings.Contains(r.Path, "/reactions") &&
+		(r.Method == http.MethodPost || r.Method == http.MethodPatch)
+}
@@ -0,0 +1,317 @@
+// Package discordtest runs a local fake of the Discord REST API and gateway
+// that discordgo can be pointed at, so handlers can be driven end to end
+// without a network.
+//
+// A typical test opens a session against the fake, registers the module
+// handlers on a router attached to it, injects an event and waits for the
+// request it causes:
+//
+//	srv := discordtest.NewServer()
+//	defer srv.Close()
+//	s, err := srv.Session()
+//	...
+//	router := discord.NewRouter(discord.New(s))
+//	math.RegisterCollatzConjectureHandler(router)
+//	router.Attach(s)
+//	srv.InjectInteraction(discordtest.CommandInteraction("collatzconjecture", ...))
+//	req, err := srv.WaitFor(discordtest.InteractionCallback, time.Second)
+//
+// discordgo keeps its endpoints in package variables, so only one Server can
+// be connected at a time and tests using it must not run in parallel.
+package discordtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// BotID is the user ID the fake gateway identifies the bot as
+	BotID = "100000000000000001"
+	// AppID is the application ID used for injected interactions
+	AppID = "100000000000000002"
+
+	gatewayPath = "/gateway-ws"
+	apiPath     = "/api/v9/" // discordgo.APIVersion
+)
+
+// Responder answers a REST request. Returning a nil body sends 204 No Content
+type Responder func(r Request) (status int, body any)
+
+// Server is a fake Discord REST API and gateway
+type Server struct {
+	HTTP *httptest.Server
+
+	mu         sync.Mutex
+	requests   []Request
+	changed    chan struct{}
+	responders []route
+	conns      map[*websocket.Conn]*sync.Mutex
+	sequence   int64
+	nextID     atomic.Int64
+	restore    func()
+}
+
+type route struct {
+	method string
+	prefix string
+	fn     Responder
+}
+
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// NewServer starts the fake on a local port
+func NewServer() *Server {
+	srv := &Server{
+		changed: make(chan struct{}),
+		conns:   make(map[*websocket.Conn]*sync.Mutex),
+	}
+	srv.nextID.Store(200000000000000000)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(gatewayPath, srv.serveGateway)
+	mux.HandleFunc(gatewayPath+"/", srv.serveGateway) // discordgo adds a trailing slash
+	mux.HandleFunc("/", srv.serveREST)
+	srv.HTTP = httptest.NewServer(mux)
+	return srv
+}
+
+// Close disconnects all sessions, stops the server and restores the real
+// discordgo endpoints
+func (srv *Server) Close() {
+	srv.mu.Lock()
+	for conn := range srv.conns {
+		conn.Close()
+	}
+	restore := srv.restore
+	srv.restore = nil
+	srv.mu.Unlock()
+
+	srv.HTTP.Close()
+	if restore != nil {
+		restore()
+	}
+}
+
+// Session points discordgo at the fake and opens a bot session against it
+func (srv *Server) Session() (*discordgo.Session, error) {
+	srv.mu.Lock()
+	if srv.restore == nil {
+		srv.restore = Repoint(srv.HTTP.URL + "/")
+	}
+	srv.mu.Unlock()
+
+	s, err := discordgo.New("Bot fake-token")
+	if err != nil {
+		return nil, err
+	}
+	s.SyncEvents = true
+	s.ShouldReconnectOnError = false
+	if err := s.Open(); err != nil {
+		return nil, fmt.Errorf("failed to connect to fake gateway: %w", err)
+	}
+	return s, nil
+}
+
+// Handle overrides the response for requests whose method matches and whose
+// path, relative to the API root, starts with prefix. Later handlers win
+func (srv *Server) Handle(method, prefix string, fn Responder) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.responders = append(srv.responders, route{method, strings.TrimPrefix(prefix, "/"), fn})
+}
+
+// Requests returns every REST request recorded so far
+func (srv *Server) Requests() []Request {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return append([]Request(nil), srv.requests...)
+}
+
+// Reset forgets the recorded requests
+func (srv *Server) Reset() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.requests = nil
+}
+
+// WaitFor blocks until a recorded request matches or timeout passes
+func (srv *Server) WaitFor(match func(Request) bool, timeout time.Duration) (Request, error) {
+	deadline := time.After(timeout)
+	seen := 0
+	for {
+		srv.mu.Lock()
+		for ; seen < len(srv.requests); seen++ {
+			if match(srv.requests[seen]) {
+				r := srv.requests[seen]
+				srv.mu.Unlock()
+				return r, nil
+			}
+		}
+		changed := srv.changed
+		srv.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return Request{}, errors.New("discordtest: no matching request before timeout")
+		}
+	}
+}
+
+func (srv *Server) serveREST(w http.ResponseWriter, r *http.Request) {
+	req, err := newRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	srv.mu.Lock()
+	srv.requests = append(srv.requests, req)
+	close(srv.changed)
+	srv.changed = make(chan struct{})
+	var responder Responder
+	for i := len(srv.responders) - 1; i >= 0; i-- {
+		route := srv.responders[i]
+		if (route.method == "" || route.method == req.Method) && strings.HasPrefix(req.Path, route.prefix) {
+			responder = route.fn
+			break
+		}
+	}
+	srv.mu.Unlock()
+
+	if responder == nil {
+		responder = srv.defaultResponse
+	}
+	status, body := responder(req)
+	if body == nil {
+		if status == 0 {
+			status = http.StatusNoContent
+		}
+		w.WriteHeader(status)
+		return
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// defaultResponse answers like Discord would for the calls the bot makes
+func (srv *Server) defaultResponse(r Request) (int, any) {
+	switch {
+	case r.Method == http.MethodGet && r.Path == "gateway":
+		return http.StatusOK, map[string]string{"url": "ws" + strings.TrimPrefix(srv.HTTP.URL, "http") + gatewayPath}
+	case r.Method == http.MethodGet && r.Path == "users/@me":
+		return http.StatusOK, discordgo.User{ID: BotID, Username: "bot", Bot: true}
+	case strings.HasPrefix(r.Path, "interactions/"):
+		return http.StatusNoContent, nil
+	case strings.Contains(r.Path, "/reactions/"):
+		return http.StatusNoContent, nil
+	case r.Method == http.MethodDelete:
+		return http.StatusNoContent, nil
+	case strings.HasPrefix(r.Path, "applications/") && strings.Contains(r.Path, "/commands"):
+		var cmd discordgo.ApplicationCommand
+		r.JSON(&cmd)
+		if cmd.ID == "" {
+			cmd.ID = srv.NewID()
+		}
+		return http.StatusOK, cmd
+	case r.Method == http.MethodPost || r.Method == http.MethodPatch:
+		return http.StatusOK, srv.echoMessage(r)
+	default:
+		return http.StatusOK, map[string]any{}
+	}
+}
+
+// echoMessage turns a message create or edit request into the message
+// Discord would return for it
+func (srv *Server) echoMessage(r Request) *discordgo.Message {
+	var sent struct {
+		Content    string                    `json:"content"`
+		Embeds     []*discordgo.MessageEmbed `json:"embeds"`
+		Components json.RawMessage           `json:"components"`
+	}
+	r.JSON(&sent)
+
+	m := &discordgo.Message{
+		ID:        srv.NewID(),
+		Content:   sent.Content,
+		Embeds:    sent.Embeds,
+		Author:    &discordgo.User{ID: BotID, Username: "bot", Bot: true},
+		Timestamp: time.Now(),
+	}
+	// messages/{id} and messages/@original keep their ID when edited
+	parts := strings.Split(r.Path, "/")
+	for i, part := range parts {
+		if part == "channels" && i+1 < len(parts) {
+			m.ChannelID = parts[i+1]
+		}
+		if part == "messages" && i+1 < len(parts) && r.Method == http.MethodPatch {
+			m.ID = parts[i+1]
+		}
+	}
+	for _, f := range r.Files {
+		m.Attachments = append(m.Attachments, &discordgo.MessageAttachment{ID: srv.NewID(), Filename: f.Name})
+	}
+	return m
+}
+
+// NewID returns a fresh snowflake-like ID
+func (srv *Server) NewID() string {
+	return strconv.FormatInt(srv.nextID.Add(1), 10)
+}
+
+// Repoint aims every discordgo REST endpoint at base and returns a function
+// restoring the previous values
+func Repoint(base string) (restore func()) {
+	vars := []*string{
+		&discordgo.EndpointDiscord, &discordgo.EndpointAPI,
+		&discordgo.EndpointGuilds, &discordgo.EndpointChannels, &discordgo.EndpointUsers,
+		&discordgo.EndpointGateway, &discordgo.EndpointGatewayBot, &discordgo.EndpointWebhooks,
+		&discordgo.EndpointStickers, &discordgo.EndpointStageInstances, &discordgo.EndpointSKUs,
+		&discordgo.EndpointVoice, &discordgo.EndpointVoiceRegions, &discordgo.EndpointNitroStickersPacks,
+		&discordgo.EndpointGuildCreate, &discordgo.EndpointApplications,
+	}
+	saved := make([]string, len(vars))
+	for i, v := range vars {
+		saved[i] = *v
+	}
+
+	api := base + strings.TrimPrefix(apiPath, "/")
+	discordgo.EndpointDiscord = base
+	discordgo.EndpointAPI = api
+	discordgo.EndpointGuilds = api + "guilds/"
+	discordgo.EndpointChannels = api + "channels/"
+	discordgo.EndpointUsers = api + "users/"
+	discordgo.EndpointGateway = api + "gateway"
+	discordgo.EndpointGatewayBot = discordgo.EndpointGateway + "/bot"
+	discordgo.EndpointWebhooks = api + "webhooks/"
+	discordgo.EndpointStickers = api + "stickers/"
+	discordgo.EndpointStageInstances = api + "stage-instances"
+	discordgo.EndpointSKUs = api + "skus"
+	discordgo.EndpointVoice = api + "voice/"
+	discordgo.EndpointVoiceRegions = discordgo.EndpointVoice + "regions"
+	discordgo.EndpointNitroStickersPacks = api + "sticker-packs"
+	discordgo.EndpointGuildCreate = api + "guilds"
+	discordgo.EndpointApplications = api + "applications"
+
+	return func() {
+		for i, v := range vars {
+			*v = saved[i]
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package doujin
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+	"test/modules/storage"
+)
+
+const (
+	scopeUser   = "user"
+	scopeServer = "server"
+)
+
+// blocklists holds the blocked tags of every server and user
+type blocklists struct {
+	Guilds map[string][]string `json:"guilds"`
+	Users  map[string][]string `json:"users"`
+}
+
+var (
+	blocklistMutex sync.RWMutex
+	blocklist      = &blocklists{
+		Guilds: make(map[string][]string),
+		Users:  make(map[string][]string),
+	}
+)
+
+func blocklistPath() string {
+	return storage.Path("doujin_blocklists.json")
+}
+
+// loadBlocklists reads the persisted blocklists
+func loadBlocklists() {
+	blocklistMutex.Lock()
+	defer blocklistMutex.Unlock()
+
+	if err := storage.Load(blocklistPath(), blocklist); err != nil {
+		log.Printf("Failed to load blocklists: %v", err)
+	}
+	if blocklist.Guilds == nil {
+		blocklist.Guilds = make(map[string][]string)
+	}
+	if blocklist.Users == nil {
+		blocklist.Users = make(map[string][]string)
+	}
+}
+
+// blockedTags returns the tags that are blocked for a user in a server
+func blockedTags(guildID, userID string, tags []string) []string {
+	blocklistMutex.RLock()
+	defer blocklistMutex.RUnlock()
+
+	var blocked []string
+	for _, tag := range tags {
+		if slices.Contains(blocklist.Guilds[guildID], tag) || slices.Contains(blocklist.Users[userID], tag) {
+			blocked = append(blocked, tag)
+		}
+	}
+	return blocked
+}
+
+// tagNames returns the lowercase names of all tags of a gallery
+func tagNames(doujin *DoujinData) []string {
+	names := make([]string, len(doujin.Tags))
+	for i, tag := range doujin.Tags {
+		names[i] = strings.ToLower(tag.Name)
+	}
+	return names
+}
+
+// handleBlocklist processes /doujin blocklist add|remove|list
+func handleBlocklist(s discord.Client, i *discordgo.InteractionCreate, group *discordgo.ApplicationCommandInteractionDataOption) {
+	if len(group.Options) == 0 {
+		discord.RespondEphemeral(s, i, "❌ Please choose a subcommand")
+		return
+	}
+	sub := group.Options[0]
+
+	scope := optionString(sub, "scope")
+	if scope == "" {
+		scope = scopeUser
+	}
+
+	var owner string
+	var lists map[string][]string
+	switch scope {
+	case scopeServer:
+		if i.GuildID == "" {
+			discord.RespondEphemeral(s, i, "❌ Server blocklists can only be used in a server")
+			return
+		}
+		if sub.Name != "list" && !discord.HasPermissions(i, discordgo.PermissionManageGuild) {
+			discord.RespondEphemeral(s, i, "❌ You need the Manage Server permission to change the server blocklist")
+			return
+		}
+		owner, lists = i.GuildID, blocklist.Guilds
+	default:
+		owner, lists = discord.UserID(i), blocklist.Users
+	}
+
+	tag := strings.ToLower(optionString(sub, "tag"))
+
+	blocklistMutex.Lock()
+	var message string
+	changed := false
+	switch sub.Name {
+	case "add":
+		if slices.Contains(lists[owner], tag) {
+			message = fmt.Sprintf("`%s` is already on the %s blocklist", tag, scope)
+		} else {
+			lists[owner] = append(lists[owner], tag)
+			sort.Strings(lists[owner])
+			message = fmt.Sprintf("✅ Added `%s` to the %s blocklist", tag, scope)
+			changed = true
+		}
+	case "remove":
+		if idx := slices.Index(lists[owner], tag); idx >= 0 {
+			lists[owner] = slices.Delete(lists[owner], idx, idx+1)
+			if len(lists[owner]) == 0 {
+				delete(lists, owner)
+			}
+			message = fmt.Sprintf("✅ Removed `%s` from the %s blocklist", tag, scope)
+			changed = true
+		} else {
+			message = fmt.Sprintf("`%s` is not on the %s blocklist", tag, scope)
+		}
+	case "list":
+		if len(lists[owner]) == 0 {
+			message = fmt.Sprintf("The %s blocklist is empty", scope)
+		} else {
+			message = fmt.Sprintf("🚫 %s blocklist: %s", strings.ToUpper(scope[:1])+scope[1:], joinLimited(lists[owner], 1900))
+		}
+	}
+
+	var err error
+	if changed {
+		err = storage.Save(blocklistPath(), blocklist)
+	}
+	blocklistMutex.Unlock()
+
+	if err != nil {
+		log.Printf("Failed to save blocklists: %v", err)
+		message += "\n⚠️ The change could not be saved and will be lost on restart"
+	}
+	discord.RespondEphemeral(s, i, message)
+}
@@ -0,0 +1,152 @@
+package doujin
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PageCache manages galleries downloaded to a local directory, keeping the
+// total size under maxBytes by evicting the least recently used galleries
+type PageCache struct {
+	root     string
+	maxBytes int64
+
+	mu       sync.Mutex
+	lastUsed map[string]time.Time
+	pins     map[string]int // galleries in use that must not be evicted
+}
+
+// Cache is the page cache used by the reader and the downloader
+var Cache = NewPageCache("./downloads", 2*1024*1024*1024)
+
+// NewPageCache returns a cache rooted at root. A maxBytes of zero means unlimited.
+func NewPageCache(root string, maxBytes int64) *PageCache {
+	return &PageCache{
+		root:     root,
+		maxBytes: maxBytes,
+		lastUsed: make(map[string]time.Time),
+		pins:     make(map[string]int),
+	}
+}
+
+// Root returns the directory galleries are downloaded to
+func (c *PageCache) Root() string {
+	return c.root
+}
+
+// PagePath returns the local path of a page if it has been downloaded
+func (c *PageCache) PagePath(code string, page int, ext string) (string, bool) {
+	path := filepath.Join(c.root, code, fmt.Sprintf("%03d.%s", page+1, ext))
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		return "", false
+	}
+
+	c.Touch(code)
+	return path, true
+}
+
+// Touch marks a gallery as recently used
+func (c *PageCache) Touch(code string) {
+	c.mu.Lock()
+	c.lastUsed[code] = time.Now()
+	c.mu.Unlock()
+}
+
+// Pin marks a gallery as in use so Evict leaves it alone until the returned
+// function is called
+func (c *PageCache) Pin(code string) (unpin func()) {
+	c.mu.Lock()
+	c.lastUsed[code] = time.Now()
+	c.pins[code]++
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			if c.pins[code]--; c.pins[code] <= 0 {
+				delete(c.pins, code)
+			}
+			c.lastUsed[code] = time.Now()
+			c.mu.Unlock()
+		})
+	}
+}
+
+// Evict removes the least recently used galleries until the cache fits in
+// maxBytes, skipping pinned galleries
+func (c *PageCache) Evict() {
+	if c.maxBytes <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(c.root)
+	if err != nil {
+		return
+	}
+
+	type gallery struct {
+		code     string
+		size     int64
+		lastUsed time.Time
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var galleries []gallery
+	var total int64
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		g := gallery{code: e.Name(), size: dirSize(filepath.Join(c.root, e.Name()))}
+		if t, ok := c.lastUsed[g.code]; ok {
+			g.lastUsed = t
+		} else if info, err := e.Info(); err == nil {
+			// galleries downloaded before the bot started have no recorded use
+			g.lastUsed = info.ModTime()
+		}
+		galleries = append(galleries, g)
+		total += g.size
+	}
+
+	sort.Slice(galleries, func(i, j int) bool { return galleries[i].lastUsed.Before(galleries[j].lastUsed) })
+	for _, g := range galleries {
+		if total <= c.maxBytes {
+			break
+		}
+		if c.pins[g.code] > 0 {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(c.root, g.code)); err != nil {
+			log.Printf("Failed to evict gallery %s: %v", g.code, err)
+			continue
+		}
+		delete(c.lastUsed, g.code)
+		total -= g.size
+		log.Printf("Evicted gallery %s from page cache (%d bytes)", g.code, g.size)
+	}
+}
+
+// dirSize returns the total size of the files in dir
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,80 @@
+package doujin
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeGallery(t *testing.T, root, code string, size int) {
+	t.Helper()
+	dir := filepath.Join(root, code)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "001.jpg"), make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvictSkipsPinnedGalleries(t *testing.T) {
+	root := t.TempDir()
+	cache := NewPageCache(root, 150)
+	writeGallery(t, root, "1", 100)
+	writeGallery(t, root, "2", 100)
+
+	unpin := cache.Pin("1")
+	cache.Touch("2") // more recently used, but "1" is in use
+	cache.Evict()
+
+	if _, err := os.Stat(filepath.Join(root, "1")); err != nil {
+		t.Errorf("pinned gallery was evicted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "2")); !os.IsNotExist(err) {
+		t.Errorf("unpinned gallery was kept")
+	}
+
+	unpin()
+	unpin() // calling it again must not unpin other users
+	writeGallery(t, root, "3", 100)
+	cache.Touch("3")
+	cache.Evict()
+	if _, err := os.Stat(filepath.Join(root, "1")); !os.IsNotExist(err) {
+		t.Errorf("unpinned gallery was kept")
+	}
+}
+
+func TestKeyedMutexSerializesPerKey(t *testing.T) {
+	var k keyedMutex
+	var mu sync.Mutex
+	active := map[string]int{}
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		key := []string{"a", "b"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := k.Lock(key)
+			defer unlock()
+
+			mu.Lock()
+			active[key]++
+			if active[key] > 1 {
+				t.Errorf("%d holders of %q", active[key], key)
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			active[key]--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(k.locks) != 0 {
+		t.Errorf("%d locks left after all were released", len(k.locks))
+	}
+}
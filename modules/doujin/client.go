@@ -0,0 +1,315 @@
+package doujin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrRateLimited = errors.New("rate limited by nhentai")
+	ErrBlocked     = errors.New("request blocked by nhentai")
+	ErrInvalidCode = errors.New("gallery codes are numbers")
+)
+
+// validCode reports whether code is a gallery code. Codes end up in API paths
+// and directory names, so nothing but digits is accepted.
+func validCode(code string) bool {
+	if code == "" || len(code) > 10 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Client talks to the nhentai API and image servers. It retries transient
+// failures and caches API responses for CacheTTL.
+type Client struct {
+	BaseURL      string // API and website, e.g. https://nhentai.net
+	ImageBaseURL string // full size pages
+	ThumbBaseURL string // covers and thumbnails
+	HTTPClient   *http.Client
+	UserAgent    string
+	Retries      int
+	CacheTTL     time.Duration
+	MaxWait      time.Duration // longest Retry-After honoured between attempts
+
+	mu    sync.Mutex
+	cache map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+// DefaultClient is shared by the info command, the reader and the downloader
+var DefaultClient = NewClient()
+
+// NewClient returns a client for the public nhentai servers
+func NewClient() *Client {
+	return &Client{
+		BaseURL:      "https://nhentai.net",
+		ImageBaseURL: "https://i.nhentai.net",
+		ThumbBaseURL: "https://t.nhentai.net",
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+		UserAgent:    "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
+		Retries:      3,
+		CacheTTL:     10 * time.Minute,
+		MaxWait:      10 * time.Second,
+	}
+}
+
+// Gallery fetches the metadata of a gallery by its code
+func (c *Client) Gallery(ctx context.Context, code string) (*DoujinData, error) {
+	if !validCode(code) {
+		return nil, ErrInvalidCode
+	}
+	var data DoujinData
+	if err := c.getJSON(ctx, "/api/gallery/"+code, &data); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("code %s %w", code, err)
+		}
+		return nil, err
+	}
+	return &data, nil
+}
+
+// SearchResult is a page of search results
+type SearchResult struct {
+	Result   []*DoujinData `json:"result"`
+	NumPages int           `json:"num_pages"`
+	PerPage  int           `json:"per_page"`
+}
+
+// Search returns a page of galleries matching query. Sort is either empty
+// for the most recent galleries or "popular".
+func (c *Client) Search(ctx context.Context, query, sort string, page int) (*SearchResult, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("page", strconv.Itoa(page))
+	if sort != "" {
+		params.Set("sort", sort)
+	}
+
+	var result SearchResult
+	if err := c.getJSON(ctx, "/api/galleries/search?"+params.Encode(), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Related returns galleries similar to the given one
+func (c *Client) Related(ctx context.Context, code string) ([]*DoujinData, error) {
+	if !validCode(code) {
+		return nil, ErrInvalidCode
+	}
+	var result SearchResult
+	if err := c.getJSON(ctx, "/api/gallery/"+code+"/related", &result); err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+// Random returns the code of a random gallery. The website answers /random/
+// with a redirect to the gallery, which is read instead of followed.
+func (c *Client) Random(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/random/", nil)
+	if err != nil {
+		return "", fmt.Errorf("request creation failed: %w", err)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	client := *c.HTTPClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return "", ErrRateLimited
+	case http.StatusForbidden:
+		return "", ErrBlocked
+	}
+
+	// Location looks like /g/123456/
+	var code int
+	if _, err := fmt.Sscanf(resp.Header.Get("Location"), "/g/%d/", &code); err != nil {
+		return "", fmt.Errorf("unexpected random response: status %d", resp.StatusCode)
+	}
+	return strconv.Itoa(code), nil
+}
+
+// GalleryURL returns the website URL of a gallery
+func (c *Client) GalleryURL(code string) string {
+	return fmt.Sprintf("%s/g/%s", c.BaseURL, code)
+}
+
+// CoverURL returns the cover image URL of a gallery
+func (c *Client) CoverURL(mediaID, ext string) string {
+	return fmt.Sprintf("%s/galleries/%s/cover.%s", c.ThumbBaseURL, mediaID, ext)
+}
+
+// PageURL returns the image URL of a zero-based page
+func (c *Client) PageURL(mediaID string, page int, ext string) string {
+	return fmt.Sprintf("%s/galleries/%s/%d.%s", c.ImageBaseURL, mediaID, page+1, ext)
+}
+
+// getJSON fetches an API path and decodes the response into v
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	body, err := c.getCached(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return nil
+}
+
+// getCached returns the body of an API path, from the cache if possible
+func (c *Client) getCached(ctx context.Context, path string) ([]byte, error) {
+	c.mu.Lock()
+	if entry, ok := c.cache[path]; ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.body, nil
+	}
+	c.mu.Unlock()
+
+	resp, err := c.get(ctx, c.BaseURL+path, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if c.CacheTTL > 0 {
+		c.mu.Lock()
+		if c.cache == nil {
+			c.cache = make(map[string]cachedResponse)
+		}
+		for key, entry := range c.cache {
+			if time.Now().After(entry.expires) {
+				delete(c.cache, key)
+			}
+		}
+		c.cache[path] = cachedResponse{body: body, expires: time.Now().Add(c.CacheTTL)}
+		c.mu.Unlock()
+	}
+
+	return body, nil
+}
+
+// get performs a GET request, retrying rate limits and server errors.
+// The caller must close the body of the returned response.
+func (c *Client) get(ctx context.Context, url, accept string) (*http.Response, error) {
+	var lastErr error
+	for attempt := 1; attempt <= max(c.Retries, 1); attempt++ {
+		resp, err := c.getOnce(ctx, url, accept)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrBlocked) || ctx.Err() != nil || attempt == max(c.Retries, 1) {
+			break
+		}
+
+		wait := time.Duration(attempt) * 500 * time.Millisecond
+		var rl *rateLimitError
+		if errors.As(err, &rl) && rl.retryAfter > 0 {
+			wait = rl.retryAfter
+		}
+		if c.MaxWait > 0 {
+			wait = min(wait, c.MaxWait)
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return nil, lastErr
+}
+
+// rateLimitError carries the delay requested by the server
+type rateLimitError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return ErrRateLimited.Error()
+}
+
+func (e *rateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+func (c *Client) getOnce(ctx context.Context, url, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return resp, nil
+	case resp.StatusCode == http.StatusNotFound:
+		err = ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		err = &rateLimitError{retryAfter: time.Duration(seconds) * time.Second}
+	case resp.StatusCode == http.StatusForbidden:
+		// Cloudflare challenges and bans
+		err = ErrBlocked
+	default:
+		err = fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+	return nil, err
+}
+
+// describeError turns client errors into a message for users
+func describeError(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		return "❌ Please provide a valid code, gallery codes are numbers"
+	case errors.Is(err, ErrNotFound):
+		return fmt.Sprintf("❌ %v", err)
+	case errors.Is(err, ErrRateLimited):
+		return "❌ nhentai is rate limiting the bot, please try again in a moment"
+	case errors.Is(err, ErrBlocked):
+		return "❌ nhentai is currently blocking the bot, please try again later"
+	default:
+		return fmt.Sprintf("❌ Error: %v", err)
+	}
+}
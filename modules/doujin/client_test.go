@@ -0,0 +1,203 @@
+package doujin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const galleryJSON = `{"id":177013,"media_id":"987654","num_pages":2,
+	"images":{"pages":[{"t":"j"},{"t":"p"}]},
+	"title":{"pretty":"Example"},
+	"tags":[{"type":"tag","name":"Example Tag"}]}`
+
+// testClient returns a client talking to an httptest server running handler
+func testClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := NewClient()
+	c.BaseURL = srv.URL
+	c.ImageBaseURL = srv.URL + "/i"
+	c.ThumbBaseURL = srv.URL + "/t"
+	c.MaxWait = 50 * time.Millisecond
+	return c
+}
+
+func TestGallery(t *testing.T) {
+	var requests atomic.Int32
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/api/gallery/177013" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(galleryJSON))
+	})
+
+	data, err := c.Gallery(context.Background(), "177013")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.MediaID != "987654" || data.Title.Pretty != "Example" || len(data.Images.Pages) != 2 {
+		t.Errorf("unexpected gallery %+v", data)
+	}
+
+	// the second lookup is answered from the cache
+	if _, err := c.Gallery(context.Background(), "177013"); err != nil {
+		t.Fatal(err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+
+	if _, err := c.Gallery(context.Background(), "1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing gallery: got %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestGalleryRejectsInvalidCodes(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+	})
+
+	for _, code := range []string{"", "../../etc", "123/related", "12a", "١٢٣", "12345678901"} {
+		if _, err := c.Gallery(context.Background(), code); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("Gallery(%q): got %v, want %v", code, err, ErrInvalidCode)
+		}
+		if _, err := c.Related(context.Background(), code); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("Related(%q): got %v, want %v", code, err, ErrInvalidCode)
+		}
+	}
+
+	_, err := DefaultDownloader.Download(context.Background(), &DoujinData{}, "..", t.TempDir(), nil)
+	if !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Download(\"..\"): got %v, want %v", err, ErrInvalidCode)
+	}
+}
+
+func TestRetryAfterIsCapped(t *testing.T) {
+	var requests atomic.Int32
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(galleryJSON))
+	})
+
+	start := time.Now()
+	if _, err := c.Gallery(context.Background(), "177013"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %v for a retry", elapsed)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+}
+
+func TestRateLimitedAfterRetries(t *testing.T) {
+	var requests atomic.Int32
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	if _, err := c.Gallery(context.Background(), "177013"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("got %v, want %v", err, ErrRateLimited)
+	}
+	if n := requests.Load(); n != int32(c.Retries) {
+		t.Errorf("got %d requests, want %d", n, c.Retries)
+	}
+}
+
+func TestBlockedIsNotRetried(t *testing.T) {
+	var requests atomic.Int32
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	if _, err := c.Gallery(context.Background(), "177013"); !errors.Is(err, ErrBlocked) {
+		t.Errorf("got %v, want %v", err, ErrBlocked)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/g/123456/", http.StatusFound)
+	})
+
+	code, err := c.Random(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "123456" {
+		t.Errorf("got code %q, want 123456", code)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	var requests atomic.Int32
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/t/galleries/987654/cover.jpg", "/i/galleries/987654/1.jpg", "/i/galleries/987654/2.png":
+			w.Write([]byte(r.URL.Path))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	var data DoujinData
+	if err := json.Unmarshal([]byte(galleryJSON), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Downloader{Workers: 2, Timeout: 5 * time.Second, Retries: 1, Client: c}
+	root := t.TempDir()
+	dir, err := d.Download(context.Background(), &data, "177013", root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"cover.jpg", "001.jpg", "002.png", manifestName} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	// a second run only verifies the files against the manifest
+	requests.Store(0)
+	var mu sync.Mutex
+	var last Progress
+	progress := func(p Progress) {
+		mu.Lock()
+		if p.Finished() > last.Finished() {
+			last = p
+		}
+		mu.Unlock()
+	}
+	if _, err := d.Download(context.Background(), &data, "177013", root, progress); err != nil {
+		t.Fatal(err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("got %d requests for downloaded files", n)
+	}
+	if last.Skipped != 3 {
+		t.Errorf("skipped %d files, want 3", last.Skipped)
+	}
+}
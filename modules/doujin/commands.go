@@ -1,18 +1,226 @@
 package doujin
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+var minPage = 1.0
+
+var galleryCode = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionString,
+	Name:        "code",
+	Description: "The nhentai doujin code (e.g., 297974)",
+	Required:    true,
+}
+
+var blocklistScope = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionString,
+	Name:        "scope",
+	Description: "Your own blocklist or the server's (defaults to your own)",
+	Choices: []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "User", Value: scopeUser},
+		{Name: "Server", Value: scopeServer},
+	},
+}
+
+var blocklistTag = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionString,
+	Name:        "tag",
+	Description: "The tag name (e.g., netorare)",
+	Required:    true,
+}
 
 var DoujinCommand = []*discordgo.ApplicationCommand{
 	{
 		Name:        "doujin",
-		Description: "Get doujin information from nhentai.net by code",
+		Description: "Get doujin information from nhentai.net",
 		Options: []*discordgo.ApplicationCommandOption{
 			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "code",
-				Description: "The nhentai doujin code (e.g., 297974)",
-				Required:    true,
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "info",
+				Description: "Get doujin information by code",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "code",
+						Description: "The nhentai doujin code (e.g., 297974)",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "export",
+				Description: "Download a doujin as a CBZ, PDF or ZIP archive",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "code",
+						Description: "The nhentai doujin code (e.g., 297974)",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "format",
+						Description: "The archive format",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "CBZ (comic book archive)", Value: formatCBZ},
+							{Name: "PDF", Value: formatPDF},
+							{Name: "ZIP", Value: formatZIP},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "search",
+				Description: "Search galleries by text and tags",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "query",
+						Description: "Text to search for",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "tags",
+						Description: "Comma separated tags the galleries must have",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "exclude",
+						Description: "Comma separated tags the galleries must not have",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "sort",
+						Description: "The order of the results",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Recent", Value: sortRecent},
+							{Name: "Popular", Value: sortPopular},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "page",
+						Description: "The result page to start at",
+						MinValue:    &minPage,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "blocklist",
+				Description: "Hide galleries with certain tags",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "add",
+						Description: "Block a tag",
+						Options:     []*discordgo.ApplicationCommandOption{blocklistTag, blocklistScope},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "remove",
+						Description: "Unblock a tag",
+						Options:     []*discordgo.ApplicationCommandOption{blocklistTag, blocklistScope},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "list",
+						Description: "Show the blocked tags",
+						Options:     []*discordgo.ApplicationCommandOption{blocklistScope},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "favorite",
+				Description: "Manage your favourite galleries",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "add",
+						Description: "Add a gallery to your favourites",
+						Options:     []*discordgo.ApplicationCommandOption{galleryCode},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "remove",
+						Description: "Remove a gallery from your favourites",
+						Options:     []*discordgo.ApplicationCommandOption{galleryCode},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "list",
+						Description: "Show your favourites",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "history",
+				Description: "Show what you have read recently and resume reading",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "random",
+				Description: "Show a random gallery",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "tag",
+						Description: "Only pick galleries with this tag",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "language",
+						Description: "Only pick galleries in this language",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "English", Value: "english"},
+							{Name: "Japanese", Value: "japanese"},
+							{Name: "Chinese", Value: "chinese"},
+						},
+					},
+				},
 			},
 		},
 	},
-}
\ No newline at end of file
+}
+
+// DoujinLimits bounds how often each /doujin subcommand runs, keyed like
+// limitName. Info, code, random and the open buttons and menus download a
+// whole gallery while they hold their slot and share one cap, export has its
+// own; the other subcommands only query the API or local data and are never
+// held up by downloads. Browsing result pages is not limited
+var DoujinLimits = map[string]discord.Limits{
+	"": {
+		PerUser:  discord.Bucket{Burst: 5, Every: 20 * time.Second},
+		PerGuild: discord.Bucket{Burst: 20, Every: 20 * time.Second},
+	},
+	"info":   downloadLimits,
+	"code":   downloadLimits,
+	"random": downloadLimits,
+	"open":   downloadLimits,
+	"browse": {},
+	"export": {
+		PerUser:    discord.Bucket{Burst: 1, Every: 30 * time.Second},
+		PerGuild:   discord.Bucket{Burst: 3, Every: 30 * time.Second},
+		Concurrent: 2,
+	},
+	"search": {
+		PerUser:  discord.Bucket{Burst: 3, Every: 20 * time.Second},
+		PerGuild: discord.Bucket{Burst: 10, Every: 20 * time.Second},
+	},
+}
+
+var downloadLimits = discord.Limits{
+	PerUser:    discord.Bucket{Burst: 3, Every: 20 * time.Second},
+	PerGuild:   discord.Bucket{Burst: 10, Every: 20 * time.Second},
+	Concurrent: 4,
+}
@@ -0,0 +1,235 @@
+package doujin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+// randomAttempts is how often /doujin random retries when it picks a blocked gallery
+const randomAttempts = 5
+
+// Carousel component custom IDs have the form "carousel:<action>:<carouselID>"
+const carouselPrefix = "carousel"
+
+const (
+	actionCarouselPrev = "prev"
+	actionCarouselNext = "next"
+	actionCarouselOpen = "open"
+)
+
+// carouselState remembers a list of galleries shown one at a time
+type carouselState struct {
+	ID       string
+	Code     string // the gallery the list is similar to
+	Results  []*DoujinData
+	Index    int
+	LastUsed time.Time
+}
+
+var carousels = make(map[string]*carouselState)
+
+// handleRandom processes /doujin random
+func handleRandom(s discord.Client, i *discordgo.InteractionCreate, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	if err := discord.Acknowledge(s, i); err != nil {
+		log.Printf("Failed to acknowledge interaction: %v", err)
+		return
+	}
+
+	var filters []string
+	if tag := optionString(sub, "tag"); tag != "" {
+		filters = append(filters, fmt.Sprintf("tag:%q", strings.ToLower(tag)))
+	}
+	if language := optionString(sub, "language"); language != "" {
+		filters = append(filters, fmt.Sprintf("language:%q", language))
+	}
+
+	userID := discord.UserID(i)
+	for attempt := 0; attempt < randomAttempts; attempt++ {
+		doujin, err := randomGallery(strings.Join(filters, " "))
+		if err != nil {
+			discord.FollowupEphemeral(s, i, describeError(err))
+			return
+		}
+		if len(blockedTags(i.GuildID, userID, tagNames(doujin))) > 0 {
+			continue
+		}
+
+		showInfo(s, i, strconv.Itoa(doujin.ID))
+		return
+	}
+
+	discord.FollowupEphemeral(s, i, "❌ Could not find a random gallery without blocked tags, please try again")
+}
+
+// randomGallery picks a random gallery, optionally among the results of a query
+func randomGallery(query string) (*DoujinData, error) {
+	ctx := context.Background()
+
+	if query == "" {
+		code, err := DefaultClient.Random(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return DefaultClient.Gallery(ctx, code)
+	}
+
+	first, err := DefaultClient.Search(ctx, query, "", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(first.Result) == 0 {
+		return nil, fmt.Errorf("no galleries match %s", query)
+	}
+
+	result := first
+	if first.NumPages > 1 {
+		if result, err = DefaultClient.Search(ctx, query, "", 1+rand.IntN(first.NumPages)); err != nil {
+			return nil, err
+		}
+		if len(result.Result) == 0 {
+			result = first
+		}
+	}
+	return result.Result[rand.IntN(len(result.Result))], nil
+}
+
+// showSimilar replies with a carousel of galleries related to code,
+// leaving out galleries with tags blocked for the user
+func showSimilar(s discord.Client, i *discordgo.InteractionCreate, code string) {
+	// the API may retry for longer than Discord waits for a response
+	if err := discord.Acknowledge(s, i); err != nil {
+		log.Printf("Failed to acknowledge interaction: %v", err)
+		return
+	}
+
+	related, err := DefaultClient.Related(context.Background(), code)
+	if err != nil {
+		discord.FollowupEphemeral(s, i, describeError(err))
+		return
+	}
+
+	results := withoutBlocked(i.GuildID, discord.UserID(i), related)
+	if len(results) == 0 {
+		discord.FollowupEphemeral(s, i, "❌ No similar galleries found")
+		return
+	}
+
+	carousel := &carouselState{
+		ID:       newSessionID(),
+		Code:     code,
+		Results:  results,
+		LastUsed: time.Now(),
+	}
+	embeds := []*discordgo.MessageEmbed{buildCarouselEmbed(carousel)}
+	components := buildCarouselComponents(carousel)
+	sessionMutex.Lock()
+	carousels[carousel.ID] = carousel
+	sessionMutex.Unlock()
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &embeds,
+		Components: &components,
+	}); err != nil {
+		log.Printf("Failed to show similar galleries: %v", err)
+	}
+}
+
+// buildCarouselEmbed shows the current gallery of a carousel with the info embed
+func buildCarouselEmbed(carousel *carouselState) *discordgo.MessageEmbed {
+	doujin := carousel.Results[carousel.Index]
+	embed := buildInfoEmbed(doujin, strconv.Itoa(doujin.ID), nil)
+	embed.Author = &discordgo.MessageEmbedAuthor{Name: fmt.Sprintf("Similar to %s", carousel.Code)}
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: fmt.Sprintf("Code: %d · %d/%d", doujin.ID, carousel.Index+1, len(carousel.Results)),
+	}
+	return embed
+}
+
+// buildCarouselComponents creates the carousel navigation buttons
+func buildCarouselComponents(carousel *carouselState) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "⬅️"}, Style: discordgo.PrimaryButton, CustomID: carouselID(actionCarouselPrev, carousel), Disabled: carousel.Index == 0},
+				discordgo.Button{Label: "Open", Emoji: &discordgo.ComponentEmoji{Name: "📖"}, Style: discordgo.SuccessButton, CustomID: carouselID(actionCarouselOpen, carousel)},
+				discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "➡️"}, Style: discordgo.PrimaryButton, CustomID: carouselID(actionCarouselNext, carousel), Disabled: carousel.Index >= len(carousel.Results)-1},
+			},
+		},
+	}
+}
+
+// handleCarouselComponent processes the carousel buttons
+func handleCarouselComponent(s discord.Client, i *discordgo.InteractionCreate) {
+	if i == nil || i.Interaction == nil || i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+
+	parts := strings.SplitN(i.MessageComponentData().CustomID, ":", 3)
+	if len(parts) != 3 || parts[0] != carouselPrefix {
+		return
+	}
+	action, carouselID := parts[1], parts[2]
+
+	if !ensureNSFW(s, i) {
+		return
+	}
+
+	// the carousel is read under the lock, other clicks and the janitor change it
+	var openCode string
+	var embed *discordgo.MessageEmbed
+	var components []discordgo.MessageComponent
+	sessionMutex.Lock()
+	carousel, exists := carousels[carouselID]
+	if exists {
+		carousel.LastUsed = time.Now()
+		switch action {
+		case actionCarouselPrev:
+			carousel.Index = max(carousel.Index-1, 0)
+		case actionCarouselNext:
+			carousel.Index = min(carousel.Index+1, len(carousel.Results)-1)
+		}
+		openCode = strconv.Itoa(carousel.Results[carousel.Index].ID)
+		embed = buildCarouselEmbed(carousel)
+		components = buildCarouselComponents(carousel)
+	}
+	sessionMutex.Unlock()
+
+	if !exists {
+		discord.RespondEphemeral(s, i, "❌ This list has expired")
+		return
+	}
+
+	if action == actionCarouselOpen {
+		if err := discord.Acknowledge(s, i); err != nil {
+			log.Printf("Failed to acknowledge interaction: %v", err)
+			return
+		}
+		showInfo(s, i, openCode)
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to update carousel: %v", err)
+	}
+}
+
+// carouselID builds the custom ID of a carousel component
+func carouselID(action string, carousel *carouselState) string {
+	return carouselPrefix + ":" + action + ":" + carousel.ID
+}
@@ -0,0 +1,111 @@
+package doujin
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const relatedJSON = `{"result":[
+	{"id":1,"media_id":"11","num_pages":1,"title":{"pretty":"One"}},
+	{"id":2,"media_id":"22","num_pages":1,"title":{"pretty":"Two"}},
+	{"id":3,"media_id":"33","num_pages":1,"title":{"pretty":"Three"}}]}`
+
+func relatedServer(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/gallery/177013/related" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte(relatedJSON))
+}
+
+// showTestCarousel opens a carousel of relatedJSON and returns its ID
+func showTestCarousel(t *testing.T) string {
+	t.Helper()
+	r := testRecorder(t)
+	useClient(t, testClient(t, relatedServer))
+
+	showSimilar(r, componentClick("u1", infoPrefix+":"+actionSimilar+":177013"), "177013")
+
+	calls := r.Calls("InteractionRespond", "InteractionResponseEdit")
+	if len(calls) != 2 || calls[0].Method != "InteractionRespond" || calls[1].Method != "InteractionResponseEdit" {
+		t.Fatalf("calls = %+v, want an acknowledgement and an edit", calls)
+	}
+	if resp := calls[0].Args[1].(*discordgo.InteractionResponse); resp.Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Errorf("first response type %d, want a deferred message", resp.Type)
+	}
+	edit := calls[1].Args[1].(*discordgo.WebhookEdit)
+	if edit.Embeds == nil || (*edit.Embeds)[0].Title != "One" {
+		t.Fatalf("carousel = %+v", edit.Embeds)
+	}
+
+	sessionMutex.RLock()
+	defer sessionMutex.RUnlock()
+	for id, carousel := range carousels {
+		if carousel.Code == "177013" {
+			t.Cleanup(func() {
+				sessionMutex.Lock()
+				delete(carousels, id)
+				sessionMutex.Unlock()
+			})
+			return id
+		}
+	}
+	t.Fatal("carousel was not stored")
+	return ""
+}
+
+func TestCarouselConcurrentClicks(t *testing.T) {
+	id := showTestCarousel(t)
+	r := testRecorder(t)
+
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		action := actionCarouselNext
+		if n%2 == 1 {
+			action = actionCarouselPrev
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handleCarouselComponent(r, componentClick("u1", carouselPrefix+":"+action+":"+id))
+		}()
+	}
+	wg.Wait()
+
+	for _, c := range r.Calls("InteractionRespond") {
+		resp := c.Args[1].(*discordgo.InteractionResponse)
+		if resp.Type != discordgo.InteractionResponseUpdateMessage || len(resp.Data.Embeds) != 1 {
+			t.Errorf("carousel response = %+v", resp)
+		}
+	}
+}
+
+func TestCarouselExpiresWhileClicked(t *testing.T) {
+	id := showTestCarousel(t)
+	r := testRecorder(t)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 10; n++ {
+			handleCarouselComponent(r, componentClick("u1", carouselPrefix+":"+actionCarouselNext+":"+id))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		expireSessions(r, time.Now().Add(time.Hour))
+	}()
+	wg.Wait()
+
+	sessionMutex.RLock()
+	_, exists := carousels[id]
+	sessionMutex.RUnlock()
+	if exists {
+		t.Error("carousel did not expire")
+	}
+}
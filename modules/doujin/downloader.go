@@ -0,0 +1,336 @@
+package doujin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// manifestName is the file in each gallery directory describing its downloaded pages
+const manifestName = "manifest.json"
+
+// Manifest records which files of a gallery have been downloaded and verified
+type Manifest struct {
+	Code      string                   `json:"code"`
+	MediaID   string                   `json:"media_id"`
+	Title     string                   `json:"title"`
+	Files     map[string]*ManifestFile `json:"files"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
+
+// ManifestFile describes a single downloaded file
+type ManifestFile struct {
+	URL    string `json:"url"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Progress reports how far a gallery download has come
+type Progress struct {
+	Done    int // files downloaded in this run
+	Skipped int // files already present and verified
+	Failed  int
+	Total   int
+}
+
+// Finished returns the number of files that have been processed
+func (p Progress) Finished() int {
+	return p.Done + p.Skipped + p.Failed
+}
+
+// ProgressFunc is called every time a file has been processed
+type ProgressFunc func(Progress)
+
+// Downloader fetches gallery files with a bounded worker pool
+type Downloader struct {
+	Workers int
+	Timeout time.Duration // per request
+	Retries int
+	Client  *Client
+
+	locks keyedMutex // one download per gallery directory at a time
+}
+
+// DefaultDownloader is used by FetchAndDownloadDoujin
+var DefaultDownloader = &Downloader{
+	Workers: 4,
+	Timeout: 30 * time.Second,
+	Retries: 3,
+	Client:  DefaultClient,
+}
+
+// keyedMutex serializes work per key
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// Lock blocks until key is free and returns the function releasing it
+func (k *keyedMutex) Lock(key string) (unlock func()) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.waiters++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		if l.waiters--; l.waiters == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// downloadJob is a single file to fetch
+type downloadJob struct {
+	url  string
+	name string
+}
+
+// Download fetches the cover and all pages of a gallery into destRoot/code.
+// Files already recorded in the gallery manifest with a matching size and hash
+// are skipped, so an interrupted download resumes where it stopped. A failing
+// file does not stop the others; all failures are returned together.
+// Concurrent downloads of the same gallery wait for each other, the later
+// ones then only verify what the first one fetched.
+func (d *Downloader) Download(ctx context.Context, data *DoujinData, code, destRoot string, progress ProgressFunc) (string, error) {
+	if !validCode(code) {
+		return "", ErrInvalidCode
+	}
+	codeDir := filepath.Join(destRoot, code)
+	unlock := d.locks.Lock(codeDir)
+	defer unlock()
+
+	if err := os.MkdirAll(codeDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	manifest := loadManifest(codeDir)
+	manifest.Code = code
+	manifest.MediaID = data.MediaID
+	manifest.Title = data.Title.Pretty
+
+	jobs := d.galleryJobs(data)
+	state := Progress{Total: len(jobs)}
+	var mu sync.Mutex
+	var errs []error
+
+	report := func(update func(p *Progress), err error) {
+		mu.Lock()
+		update(&state)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		snapshot := state
+		mu.Unlock()
+		if progress != nil {
+			progress(snapshot)
+		}
+	}
+
+	queue := make(chan downloadJob)
+	var wg sync.WaitGroup
+	for w := 0; w < max(d.Workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				path := filepath.Join(codeDir, job.name)
+
+				mu.Lock()
+				recorded := manifest.Files[job.name]
+				mu.Unlock()
+				if verifyFile(path, recorded) {
+					report(func(p *Progress) { p.Skipped++ }, nil)
+					continue
+				}
+
+				file, err := d.fetch(ctx, job.url, path)
+				if err != nil {
+					report(func(p *Progress) { p.Failed++ }, fmt.Errorf("%s: %w", job.name, err))
+					continue
+				}
+
+				mu.Lock()
+				manifest.Files[job.name] = file
+				saveManifest(codeDir, manifest)
+				mu.Unlock()
+				report(func(p *Progress) { p.Done++ }, nil)
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		select {
+		case queue <- job:
+		case <-ctx.Done():
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	saveManifest(codeDir, manifest)
+
+	if err := ctx.Err(); err != nil {
+		return codeDir, err
+	}
+	if len(errs) > 0 {
+		return codeDir, fmt.Errorf("failed to download %d of %d files: %w", len(errs), len(jobs), errors.Join(errs...))
+	}
+	return codeDir, nil
+}
+
+// galleryJobs lists the cover and every page of a gallery
+func (d *Downloader) galleryJobs(data *DoujinData) []downloadJob {
+	coverExt := "jpg"
+	if len(data.Images.Pages) > 0 {
+		coverExt = getExtension(data.Images.Pages[0].Type)
+	}
+
+	jobs := []downloadJob{{
+		url:  d.Client.CoverURL(data.MediaID, coverExt),
+		name: fmt.Sprintf("cover.%s", coverExt),
+	}}
+	for i, p := range data.Images.Pages {
+		ext := getExtension(p.Type)
+		jobs = append(jobs, downloadJob{
+			url:  d.Client.PageURL(data.MediaID, i, ext),
+			name: fmt.Sprintf("%03d.%s", i+1, ext),
+		})
+	}
+	return jobs
+}
+
+// fetch downloads url to path with retries, hashing the content on the way
+func (d *Downloader) fetch(ctx context.Context, url, path string) (*ManifestFile, error) {
+	var lastErr error
+	for attempt := 1; attempt <= max(d.Retries, 1); attempt++ {
+		file, err := d.fetchOnce(ctx, url, path)
+		if err == nil {
+			return file, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-time.After(time.Duration(attempt) * 500 * time.Millisecond):
+		case <-ctx.Done():
+		}
+	}
+	return nil, lastErr
+}
+
+// fetchOnce performs a single download into a temporary file that is only
+// renamed into place once it is complete
+func (d *Downloader) fetchOnce(ctx context.Context, url, path string) (*ManifestFile, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	resp, err := d.Client.getOnce(ctx, url, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	partPath := path + ".part"
+	f, err := os.Create(partPath)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), resp.Body)
+	f.Close()
+	if err == nil && resp.ContentLength >= 0 && size != resp.ContentLength {
+		err = fmt.Errorf("incomplete download: got %d of %d bytes", size, resp.ContentLength)
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return nil, err
+	}
+
+	if err := os.Rename(partPath, path); err != nil {
+		os.Remove(partPath)
+		return nil, err
+	}
+
+	return &ManifestFile{URL: url, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// verifyFile checks that a file on disk matches its manifest entry
+func verifyFile(path string, recorded *ManifestFile) bool {
+	if recorded == nil {
+		return false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.Size() != recorded.Size {
+		return false
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(hash.Sum(nil)) == recorded.SHA256
+}
+
+// loadManifest reads the manifest of a gallery directory, returning an empty
+// one if it does not exist yet
+func loadManifest(codeDir string) *Manifest {
+	manifest := &Manifest{}
+	if data, err := os.ReadFile(filepath.Join(codeDir, manifestName)); err == nil {
+		json.Unmarshal(data, manifest)
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]*ManifestFile)
+	}
+	return manifest
+}
+
+// saveManifest writes the manifest atomically
+func saveManifest(codeDir string, manifest *Manifest) error {
+	manifest.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(codeDir, manifestName)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
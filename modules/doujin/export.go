@@ -0,0 +1,385 @@
+package doujin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/artifact"
+	"test/modules/discord"
+)
+
+const (
+	formatCBZ = "cbz"
+	formatPDF = "pdf"
+	formatZIP = "zip"
+)
+
+// handleExport processes /doujin export
+func handleExport(s discord.Client, i *discordgo.InteractionCreate, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	code := optionString(sub, "code")
+	format := optionString(sub, "format")
+	if !validCode(code) {
+		discord.RespondEphemeral(s, i, describeError(ErrInvalidCode))
+		return
+	}
+	if format != formatCBZ && format != formatPDF && format != formatZIP {
+		discord.RespondEphemeral(s, i, "❌ Format must be cbz, pdf or zip")
+		return
+	}
+
+	if err := discord.Acknowledge(s, i); err != nil {
+		log.Printf("Failed to acknowledge interaction: %v", err)
+		return
+	}
+
+	// the pages are read after the download, keep them until the archive is done
+	defer Cache.Evict()
+	unpin := Cache.Pin(code)
+	defer unpin()
+
+	doujin, err := DefaultClient.Gallery(context.Background(), code)
+	if err != nil {
+		editResponse(s, i, describeError(err), nil)
+		return
+	}
+	if blocked := blockedTags(i.GuildID, discord.UserID(i), tagNames(doujin)); len(blocked) > 0 {
+		editResponse(s, i, fmt.Sprintf("🚫 This gallery contains blocked tags: %s", strings.Join(blocked, ", ")), nil)
+		return
+	}
+
+	codeDir, err := DefaultDownloader.Download(context.Background(), doujin, code, Cache.Root(), nil)
+	if err != nil {
+		editResponse(s, i, describeError(err), nil)
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "doujin-export-*."+format)
+	if err != nil {
+		log.Printf("Failed to create export file: %v", err)
+		editResponse(s, i, "❌ Failed to create the archive", nil)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	pages := pageFiles(doujin, codeDir)
+	switch format {
+	case formatCBZ:
+		err = writeCBZ(tmp, doujin, code, pages)
+	case formatPDF:
+		err = writePDF(tmp, pages)
+	case formatZIP:
+		err = writeZip(tmp, pages, nil)
+	}
+	if err != nil {
+		log.Printf("Failed to export %s as %s: %v", code, format, err)
+		editResponse(s, i, fmt.Sprintf("❌ Failed to create the archive: %v", err), nil)
+		return
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		editResponse(s, i, "❌ Failed to create the archive", nil)
+		return
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		editResponse(s, i, "❌ Failed to create the archive", nil)
+		return
+	}
+
+	name := fmt.Sprintf("%s.%s", code, format)
+	summary := fmt.Sprintf("📦 **%s** exported as %s (%d pages, %.1f MB)",
+		doujin.Title.Pretty, strings.ToUpper(format), len(pages), float64(info.Size())/1024/1024)
+
+	if info.Size() <= artifact.UploadLimit {
+		editResponse(s, i, summary, []*discordgo.File{
+			{Name: name, ContentType: archiveContentType(format), Reader: tmp},
+		})
+		return
+	}
+
+	// too large to attach, keep it in the artifact store instead
+	stored, err := artifact.Keep(name, archiveContentType(format), tmp)
+	if errors.Is(err, artifact.ErrTooLarge) {
+		editResponse(s, i, summary+"\n❌ The archive is too large to upload or download", nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to store export of %s: %v", code, err)
+		editResponse(s, i, summary+"\n❌ The archive is too large to upload and could not be stored", nil)
+		return
+	}
+	editResponse(s, i, summary+"\n"+artifact.Reference(stored), nil)
+}
+
+// pageFiles returns the local paths of all downloaded pages in reading order
+func pageFiles(doujin *DoujinData, codeDir string) []string {
+	pages := make([]string, len(doujin.Images.Pages))
+	for i, p := range doujin.Images.Pages {
+		pages[i] = filepath.Join(codeDir, fmt.Sprintf("%03d.%s", i+1, getExtension(p.Type)))
+	}
+	return pages
+}
+
+// writeZip stores pages and extra files in a zip archive. Pages are already
+// compressed images, so they are stored without compression.
+func writeZip(w io.Writer, pages []string, extra map[string][]byte) error {
+	zw := zip.NewWriter(w)
+
+	for name, data := range extra {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+	}
+
+	for _, page := range pages {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     filepath.Base(page),
+			Method:   zip.Store,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(page)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// comicInfo is the ComicInfo.xml metadata read by comic book readers
+type comicInfo struct {
+	XMLName   xml.Name `xml:"ComicInfo"`
+	Title     string   `xml:"Title"`
+	Writer    string   `xml:"Writer,omitempty"`
+	Genre     string   `xml:"Genre,omitempty"`
+	Tags      string   `xml:"Tags,omitempty"`
+	Web       string   `xml:"Web"`
+	PageCount int      `xml:"PageCount"`
+	Language  string   `xml:"LanguageISO,omitempty"`
+	Manga     string   `xml:"Manga"`
+	AgeRating string   `xml:"AgeRating"`
+}
+
+// writeCBZ writes a comic book archive with ComicInfo.xml metadata
+func writeCBZ(w io.Writer, doujin *DoujinData, code string, pages []string) error {
+	grouped := extractTags(doujin)
+
+	info := comicInfo{
+		Title:     doujin.Title.Pretty,
+		Writer:    strings.Join(grouped["artist"], ", "),
+		Genre:     strings.Join(grouped["category"], ", "),
+		Tags:      strings.Join(grouped["tag"], ", "),
+		Web:       DefaultClient.GalleryURL(code),
+		PageCount: len(pages),
+		Language:  languageISO(grouped["language"]),
+		Manga:     "YesAndRightToLeft",
+		AgeRating: "Adults Only 18+",
+	}
+
+	data, err := xml.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeZip(w, pages, map[string][]byte{
+		"ComicInfo.xml": append([]byte(xml.Header), data...),
+	})
+}
+
+// languageISO maps nhentai language tags to ISO 639-1 codes
+func languageISO(languages []string) string {
+	codes := map[string]string{
+		"english":  "en",
+		"japanese": "ja",
+		"chinese":  "zh",
+	}
+	for _, l := range languages {
+		if code, ok := codes[l]; ok {
+			return code
+		}
+	}
+	return ""
+}
+
+// writePDF writes a PDF with one page per image, each page sized to its image.
+// JPEG images are embedded as-is, other formats are converted to JPEG.
+func writePDF(w io.Writer, pages []string) error {
+	pdf := &pdfWriter{w: w}
+	pdf.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// objects 1 and 2 are the catalog and the page tree, each page uses three
+	// objects: the page, its content stream and its image
+	pageRefs := make([]string, len(pages))
+	for i := range pages {
+		pageRefs[i] = fmt.Sprintf("%d 0 R", 3+i*3)
+	}
+
+	pdf.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	pdf.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(pages)))
+
+	for i, page := range pages {
+		data, width, height, err := jpegPage(page)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(page), err)
+		}
+
+		pageObj, contentObj, imageObj := 3+i*3, 4+i*3, 5+i*3
+		pdf.object(pageObj, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+			width, height, imageObj, contentObj))
+
+		content := fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", width, height)
+		pdf.stream(contentObj, "", []byte(content))
+		pdf.stream(imageObj, fmt.Sprintf(
+			"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode",
+			width, height), data)
+	}
+
+	return pdf.finish(1)
+}
+
+// jpegPage returns the page as JPEG data with its dimensions
+func jpegPage(path string) ([]byte, int, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	// grayscale and CMYK JPEGs would need another color space, re-encode them as RGB
+	if format == "jpeg" && cfg.ColorModel == color.YCbCrModel {
+		return data, cfg.Width, cfg.Height, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	// the encoder writes grayscale images with a single component, which
+	// does not match the /DeviceRGB color space of the image object
+	rgb := image.NewRGBA(img.Bounds())
+	draw.Draw(rgb, rgb.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, rgb, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, 0, 0, err
+	}
+	return buf.Bytes(), cfg.Width, cfg.Height, nil
+}
+
+// pdfWriter tracks object offsets while writing a PDF
+type pdfWriter struct {
+	w       io.Writer
+	offset  int
+	offsets map[int]int
+	err     error
+}
+
+func (p *pdfWriter) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(p.w, format, args...)
+	p.offset += n
+	p.err = err
+}
+
+func (p *pdfWriter) write(data []byte) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.Write(data)
+	p.offset += n
+	p.err = err
+}
+
+func (p *pdfWriter) begin(id int) {
+	if p.offsets == nil {
+		p.offsets = make(map[int]int)
+	}
+	p.offsets[id] = p.offset
+	p.printf("%d 0 obj\n", id)
+}
+
+func (p *pdfWriter) object(id int, body string) {
+	p.begin(id)
+	p.printf("%s\nendobj\n", body)
+}
+
+func (p *pdfWriter) stream(id int, dict string, data []byte) {
+	p.begin(id)
+	p.printf("<< %s /Length %d >>\nstream\n", dict, len(data))
+	p.write(data)
+	p.printf("\nendstream\nendobj\n")
+}
+
+// finish writes the cross-reference table and trailer
+func (p *pdfWriter) finish(root int) error {
+	xref := p.offset
+	count := len(p.offsets) + 1
+	p.printf("xref\n0 %d\n0000000000 65535 f \n", count)
+	for id := 1; id < count; id++ {
+		p.printf("%010d 00000 n \n", p.offsets[id])
+	}
+	p.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", count, root, xref)
+	return p.err
+}
+
+func archiveContentType(format string) string {
+	switch format {
+	case formatPDF:
+		return "application/pdf"
+	case formatCBZ:
+		return "application/vnd.comicbook+zip"
+	default:
+		return "application/zip"
+	}
+}
+
+// editResponse replaces the deferred response with content and optional files
+func editResponse(s discord.Client, i *discordgo.InteractionCreate, content string, files []*discordgo.File) {
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+		Files:   files,
+	}); err != nil {
+		log.Printf("Failed to edit response: %v", err)
+	}
+}
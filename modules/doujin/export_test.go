@@ -0,0 +1,69 @@
+package doujin
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJPEGPageConvertsToRGB(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 8, 4))
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i * 8)
+	}
+
+	dir := t.TempDir()
+	var grayJPEG, grayPNG bytes.Buffer
+	if err := jpeg.Encode(&grayJPEG, gray, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(&grayPNG, gray); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{"001.jpg": grayJPEG.Bytes(), "002.png": grayPNG.Bytes()} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		out, width, height, err := jpegPage(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if width != 8 || height != 4 {
+			t.Errorf("%s: got %dx%d, want 8x4", name, width, height)
+		}
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(out))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if format != "jpeg" || cfg.ColorModel != color.YCbCrModel {
+			t.Errorf("%s: got %s with %T, want an RGB jpeg", name, format, cfg.ColorModel)
+		}
+	}
+}
+
+func TestJPEGPageKeepsRGBJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "001.jpg")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, _, err := jpegPage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, buf.Bytes()) {
+		t.Error("RGB jpeg was re-encoded")
+	}
+}
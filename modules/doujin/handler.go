@@ -1,67 +1,157 @@
 package doujin
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
 )
 
 // RegisterDoujinHandler sets up command and reaction handlers
-func RegisterDoujinHandler(session *discordgo.Session) {
-	session.AddHandler(handleCommand)
-	session.AddHandler(handleReaction)
+func RegisterDoujinHandler(r *discord.Router) {
+	registerRoutes(r)
+
+	loadBlocklists()
+	loadLibraries()
+
+	go runSessionJanitor(r.Client())
+}
+
+// registerRoutes adds the command, component and reaction handlers to r. The
+// 📖 reaction opens a reader, so it needs the same access as /doujin
+func registerRoutes(r *discord.Router) {
+	limit := discord.RateLimitNamed(DoujinLimits, limitName)
+	r.Command(DoujinCommand[0], handleCommand, limit)
+	r.ReactionFor(DoujinCommand[0], handleReaction)
+	r.Component(DoujinCommand[0], readerPrefix+":", handleReaderComponent)
+	r.Component(DoujinCommand[0], infoPrefix+":", handleInfoComponent)
+	r.Component(DoujinCommand[0], searchPrefix+":", handleSearchComponent, limit)
+	r.Component(DoujinCommand[0], libraryPrefix+":", handleLibraryComponent, limit)
+	r.Component(DoujinCommand[0], carouselPrefix+":", handleCarouselComponent, limit)
 }
 
-// handleCommand processes the /doujin command
-func handleCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+// limitName picks the DoujinLimits of an interaction: the subcommand for
+// /doujin, "open" for the components that show a gallery and "browse" for
+// the other components
+func limitName(i *discordgo.InteractionCreate) string {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return discord.SubcommandName(i)
+	}
+
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) < 2 {
+		return "browse"
+	}
+	switch {
+	case parts[0] == searchPrefix && parts[1] == actionSearchOpen,
+		parts[0] == carouselPrefix && parts[1] == actionCarouselOpen,
+		parts[0] == libraryPrefix && parts[1] == actionOpenInfo:
+		return "open"
+	}
+	return "browse"
+}
+
+// handleCommand processes the /doujin command and dispatches its subcommands
+func handleCommand(s discord.Client, i *discordgo.InteractionCreate) {
 	if !isDoujinCommand(i) {
 		return
 	}
 
-	code := extractCode(i)
-	if code == "" {
-		respondError(s, i, "❌ Please provide a valid code")
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		discord.RespondEphemeral(s, i, "❌ Please choose a subcommand")
+		return
+	}
+
+	// blocklists may be managed anywhere, everything else shows NSFW content
+	if options[0].Name != "blocklist" && !ensureNSFW(s, i) {
+		return
+	}
+
+	switch sub := options[0]; sub.Name {
+	case "info":
+		handleInfo(s, i, sub)
+	case "code":
+		// /doujin code: was replaced by /doujin info code:, clients may still
+		// send the old form until they pick up the new command definition
+		handleInfo(s, i, &discordgo.ApplicationCommandInteractionDataOption{Name: "info", Options: options})
+	case "export":
+		handleExport(s, i, sub)
+	case "search":
+		handleSearch(s, i, sub)
+	case "blocklist":
+		handleBlocklist(s, i, sub)
+	case "favorite":
+		handleFavorite(s, i, sub)
+	case "history":
+		handleHistory(s, i)
+	case "random":
+		handleRandom(s, i, sub)
+	}
+}
+
+// handleInfo processes /doujin info
+func handleInfo(s discord.Client, i *discordgo.InteractionCreate, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	code := optionString(sub, "code")
+	if !validCode(code) {
+		discord.RespondEphemeral(s, i, describeError(ErrInvalidCode))
 		return
 	}
 
-	if err := acknowledgeInteraction(s, i); err != nil {
+	if err := discord.Acknowledge(s, i); err != nil {
 		log.Printf("Failed to acknowledge interaction: %v", err)
 		return
 	}
 
-	doujin, err := fetchDoujin(code)
+	showInfo(s, i, code)
+}
+
+// showInfo sends the info embed of a gallery as a followup to an acknowledged
+// interaction, then downloads the gallery into the page cache
+func showInfo(s discord.Client, i *discordgo.InteractionCreate, code string) {
+	doujin, err := DefaultClient.Gallery(context.Background(), code)
 	if err != nil {
-		sendFollowupError(s, i, fmt.Sprintf("❌ Error: %v", err))
+		discord.FollowupEphemeral(s, i, describeError(err))
 		return
 	}
 
-	embed := buildInfoEmbed(doujin, code)
-	msg, err := sendFollowup(s, i, embed)
+	blocked := blockedTags(i.GuildID, discord.UserID(i), tagNames(doujin))
+	embed := buildInfoEmbed(doujin, code, blocked)
+	msg, err := sendFollowup(s, i, embed, buildInfoComponents(doujin, code))
 	if err != nil {
 		log.Printf("Failed to send followup (error): %v", err)
-		sendFollowupError(s, i, "❌ Failed to send message")
+		discord.FollowupEphemeral(s, i, "❌ Failed to send message")
 		return
 	}
 	if msg == nil {
 		log.Printf("Failed to send followup: message is nil but no error returned")
-		sendFollowupError(s, i, "❌ Failed to send message (nil response)")
+		discord.FollowupEphemeral(s, i, "❌ Failed to send message (nil response)")
+		return
+	}
+
+	// galleries with blocked tags can not be read
+	if len(blocked) > 0 {
 		return
 	}
 
-	storeSession(msg.ID, createSession(doujin, code, msg.ChannelID, getUserID(i)))
+	storeSession(msg.ID, createSession(doujin, code, i.GuildID, msg.ChannelID, discord.UserID(i)))
 	err = s.MessageReactionAdd(msg.ChannelID, msg.ID, "📖")
 	if err != nil {
 		log.Printf("Failed to add reaction: %v", err)
 		return
 	}
 
-	downloadDoujin, s2, err := FetchAndDownloadDoujin(code, "./downloads")
+	defer Cache.Evict()
+	unpin := Cache.Pin(code)
+	defer unpin()
+
+	downloadDoujin, s2, err := FetchAndDownloadDoujin(code, Cache.Root(), downloadProgress(s, i, msg.ID))
 	if err != nil {
 		log.Printf("Failed to fetch and download doujin: %v", err)
 		return
@@ -76,17 +166,46 @@ func handleCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 }
 
+// downloadProgress returns a progress callback that shows the download state
+// on the info message, editing it at most every few seconds
+func downloadProgress(s discord.Client, i *discordgo.InteractionCreate, msgID string) ProgressFunc {
+	var mu sync.Mutex
+	var lastEdit time.Time
+
+	return func(p Progress) {
+		finished := p.Finished() == p.Total
+
+		mu.Lock()
+		if !finished && time.Since(lastEdit) < 3*time.Second {
+			mu.Unlock()
+			return
+		}
+		lastEdit = time.Now()
+		mu.Unlock()
+
+		status := fmt.Sprintf("📥 Downloading pages: %d/%d", p.Finished(), p.Total)
+		if finished {
+			status = fmt.Sprintf("✅ Downloaded %d/%d files", p.Done+p.Skipped, p.Total)
+			if p.Failed > 0 {
+				status += fmt.Sprintf(" (%d failed)", p.Failed)
+			}
+		}
+
+		content := "🔞 NSFW Content\n" + status
+		if _, err := s.FollowupMessageEdit(i.Interaction, msgID, &discordgo.WebhookEdit{Content: &content}); err != nil {
+			log.Printf("Failed to update download progress: %v", err)
+		}
+	}
+}
+
 // handleReaction processes emoji reactions
-func handleReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+func handleReaction(s discord.Client, r *discordgo.MessageReactionAdd) {
 	if !isValidReaction(s, r) {
 		return
 	}
 
-	switch r.Emoji.Name {
-	case "📖":
+	if r.Emoji.Name == "📖" {
 		openReader(s, r)
-	case "⬅️", "➡️", "⏹️":
-		navigateReader(s, r)
 	}
 }
 
@@ -99,286 +218,262 @@ func isDoujinCommand(i *discordgo.InteractionCreate) bool {
 }
 
 // isValidReaction checks if reaction is valid and not from bot
-func isValidReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd) bool {
+func isValidReaction(s discord.Client, r *discordgo.MessageReactionAdd) bool {
 	return s != nil &&
 		r != nil &&
-		s.State != nil &&
-		s.State.User != nil &&
-		r.UserID != s.State.User.ID
+		s.BotUserID() != "" &&
+		r.UserID != s.BotUserID()
 }
 
-// extractCode gets the code parameter from command options
-func extractCode(i *discordgo.InteractionCreate) string {
-	options := i.ApplicationCommandData().Options
-	if len(options) == 0 {
-		return ""
+// optionString gets a string parameter from subcommand options
+func optionString(sub *discordgo.ApplicationCommandInteractionDataOption, name string) string {
+	for _, option := range sub.Options {
+		if option.Name == name {
+			return strings.TrimSpace(option.StringValue())
+		}
 	}
-	return strings.TrimSpace(options[0].StringValue())
+	return ""
 }
 
-// fetchDoujin retrieves data from the API
-func fetchDoujin(code string) (*DoujinData, error) {
-	url := fmt.Sprintf("https://nhentai.net/api/gallery/%s", code)
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("request creation failed: %v", err)
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("code %s not found", code)
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
-	}
-
-	var data DoujinData
-	if err := json.Unmarshal(body, &data); err != nil {
-		return nil, fmt.Errorf("invalid JSON response: %v", err)
-	}
-
-	return &data, nil
+// tagGroups lists the tag types shown on the info embed, in display order
+var tagGroups = []struct {
+	Type  string
+	Title string
+}{
+	{"parody", "Parodies"},
+	{"character", "Characters"},
+	{"artist", "Artists"},
+	{"group", "Groups"},
+	{"language", "Languages"},
+	{"category", "Categories"},
+	{"tag", "Tags"},
 }
 
-// buildInfoEmbed creates the information embed
-func buildInfoEmbed(doujin *DoujinData, code string) *discordgo.MessageEmbed {
-	artists, languages, tags := extractTags(doujin)
+// maxEmbedTags is the number of plain tags shown before "Show all tags" is needed
+const maxEmbedTags = 10
 
+// buildInfoEmbed creates the information embed. The cover is withheld when
+// the gallery contains blocked tags.
+func buildInfoEmbed(doujin *DoujinData, code string, blocked []string) *discordgo.MessageEmbed {
 	embed := &discordgo.MessageEmbed{
 		Title: doujin.Title.Pretty,
-		URL:   fmt.Sprintf("https://nhentai.net/g/%s", code),
+		URL:   DefaultClient.GalleryURL(code),
 		Color: 0x8A2BE2,
 		Fields: []*discordgo.MessageEmbedField{
 			{Name: "Pages", Value: fmt.Sprintf("%d", doujin.NumPages), Inline: true},
+			{Name: "Favourites", Value: fmt.Sprintf("%d", doujin.NumFavorites), Inline: true},
 		},
 		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Code: %s", code)},
 	}
 
-	if len(doujin.Images.Pages) > 0 {
-		ext := getExtension(doujin.Images.Pages[0].Type)
-		embed.Image = &discordgo.MessageEmbedImage{
-			URL: fmt.Sprintf("https://t.nhentai.net/galleries/%s/cover.%s", doujin.MediaID, ext),
-		}
+	var titles []string
+	if doujin.Title.English != "" {
+		titles = append(titles, doujin.Title.English)
+	}
+	if doujin.Title.Japanese != "" {
+		titles = append(titles, doujin.Title.Japanese)
 	}
+	embed.Description = strings.Join(titles, "\n")
 
-	if len(artists) > 0 {
+	if doujin.UploadDate > 0 {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name: "Artists", Value: strings.Join(artists, ", "), Inline: true,
+			Name: "Uploaded", Value: fmt.Sprintf("<t:%d:D>", doujin.UploadDate), Inline: true,
 		})
+		embed.Timestamp = time.Unix(doujin.UploadDate, 0).Format(time.RFC3339)
 	}
-	if len(languages) > 0 {
+	if doujin.Scanlator != "" {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name: "Languages", Value: strings.Join(languages, ", "), Inline: true,
+			Name: "Scanlator", Value: doujin.Scanlator, Inline: true,
 		})
 	}
-	if len(tags) > 0 {
-		maxTags := min(5, len(tags))
-		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name: "Tags", Value: strings.Join(tags[:maxTags], ", "), Inline: false,
-		})
+
+	if len(blocked) > 0 {
+		embed.Description += fmt.Sprintf("\n\n🚫 Cover and reader hidden, this gallery contains blocked tags: %s", strings.Join(blocked, ", "))
+		embed.Color = 0x808080
+	} else if len(doujin.Images.Pages) > 0 {
+		ext := getExtension(doujin.Images.Pages[0].Type)
+		embed.Image = &discordgo.MessageEmbedImage{
+			URL: DefaultClient.CoverURL(doujin.MediaID, ext),
+		}
 	}
 
+	embed.Fields = append(embed.Fields, tagFields(doujin, maxEmbedTags)...)
 	return embed
 }
 
-// extractTags separates tags by type
-func extractTags(doujin *DoujinData) (artists, languages, tags []string) {
-	for _, tag := range doujin.Tags {
-		switch tag.Type {
-		case "artist":
-			artists = append(artists, tag.Name)
-		case "language":
-			languages = append(languages, tag.Name)
-		case "tag":
-			tags = append(tags, tag.Name)
+// tagFields returns one embed field per tag type. Plain tags are cut to
+// maxTags when it is positive, other types are always shown in full.
+func tagFields(doujin *DoujinData, maxTags int) []*discordgo.MessageEmbedField {
+	grouped := extractTags(doujin)
+
+	var fields []*discordgo.MessageEmbedField
+	for _, group := range tagGroups {
+		names := grouped[group.Type]
+		if len(names) == 0 {
+			continue
 		}
+
+		value := joinLimited(names, 1024)
+		if group.Type == "tag" && maxTags > 0 && len(names) > maxTags {
+			value = joinLimited(names[:maxTags], 1000) + fmt.Sprintf(" (+%d more)", len(names)-maxTags)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: group.Title, Value: value, Inline: group.Type != "tag",
+		})
 	}
-	return
+	return fields
 }
 
-// openReader opens a new reader session
-func openReader(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
-	sessionMutex.RLock()
-	original, exists := originalMessages[r.MessageID]
-	sessionMutex.RUnlock()
-
-	if !exists || original == nil || len(original.PageExts) == 0 {
-		return
+// joinLimited joins names with commas, stopping before the result exceeds limit characters
+func joinLimited(names []string, limit int) string {
+	var b strings.Builder
+	for i, name := range names {
+		sep := ""
+		if i > 0 {
+			sep = ", "
+		}
+		if b.Len()+len(sep)+len(name) > limit-4 {
+			b.WriteString(sep + "…")
+			break
+		}
+		b.WriteString(sep + name)
 	}
+	return b.String()
+}
 
-	embed := buildReaderEmbed(original, 0)
-	msg, err := s.ChannelMessageSendEmbed(original.ChannelID, embed)
-	if err != nil || msg == nil {
-		log.Printf("Failed to send reader embed: %v", err)
-		return
+// extractTags groups tag names by tag type
+func extractTags(doujin *DoujinData) map[string][]string {
+	grouped := make(map[string][]string)
+	for _, tag := range doujin.Tags {
+		grouped[tag.Type] = append(grouped[tag.Type], tag.Name)
 	}
+	return grouped
+}
 
-	addNavigationReactions(s, msg)
-
-	newSession := &ReadSession{
-		OwnerID:   r.UserID,
-		MediaID:   original.MediaID,
-		PageExts:  original.PageExts,
-		Current:   0,
-		Total:     original.Total,
-		ChannelID: msg.ChannelID,
-		Code:      original.Code,
+// buildInfoComponents creates the buttons below the info embed
+func buildInfoComponents(doujin *DoujinData, code string) []discordgo.MessageComponent {
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{
+			Label:    "Similar",
+			Emoji:    &discordgo.ComponentEmoji{Name: "🔀"},
+			Style:    discordgo.SecondaryButton,
+			CustomID: infoPrefix + ":" + actionSimilar + ":" + code,
+		},
+	}
+	if len(extractTags(doujin)["tag"]) > maxEmbedTags {
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Show all tags",
+			Style:    discordgo.SecondaryButton,
+			CustomID: infoPrefix + ":" + actionAllTags + ":" + code,
+		})
 	}
 
-	sessionMutex.Lock()
-	activeReaders[msg.ID] = newSession
-	sessionMutex.Unlock()
-
-	s.MessageReactionRemove(original.ChannelID, r.MessageID, "📖", r.UserID)
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+	}
 }
 
-// navigateReader handles page navigation
-func navigateReader(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
-	sessionMutex.RLock()
-	session, exists := activeReaders[r.MessageID]
-	sessionMutex.RUnlock()
-
-	if !exists || session == nil || r.UserID != session.OwnerID {
-		s.MessageReactionRemove(r.ChannelID, r.MessageID, r.Emoji.Name, r.UserID)
-		return
-	}
+// Info message component custom IDs have the form "doujin:<action>:<code>"
+const infoPrefix = "doujin"
 
-	emoji := r.Emoji.Name
+const (
+	actionAllTags = "tags"
+	actionSimilar = "similar"
+)
 
-	if emoji == "⏹️" {
-		s.ChannelMessageDelete(session.ChannelID, r.MessageID)
-		sessionMutex.Lock()
-		delete(activeReaders, r.MessageID)
-		sessionMutex.Unlock()
+// handleInfoComponent processes the buttons on the info embed
+func handleInfoComponent(s discord.Client, i *discordgo.InteractionCreate) {
+	if i == nil || i.Interaction == nil || i.Type != discordgo.InteractionMessageComponent {
 		return
 	}
 
-	if emoji == "⬅️" && session.Current > 0 {
-		session.Current--
-	} else if emoji == "➡️" && session.Current < session.Total-1 {
-		session.Current++
+	parts := strings.SplitN(i.MessageComponentData().CustomID, ":", 3)
+	if len(parts) != 3 || parts[0] != infoPrefix {
+		return
 	}
+	action, code := parts[1], parts[2]
 
-	updateReader(s, session, r.MessageID)
-	s.MessageReactionRemove(session.ChannelID, r.MessageID, emoji, r.UserID)
-}
-
-// buildReaderEmbed creates a reader page embed
-func buildReaderEmbed(session *ReadSession, page int) *discordgo.MessageEmbed {
-	if page < 0 || page >= len(session.PageExts) {
-		log.Printf("Invalid page index: %d (total pages: %d)", page, len(session.PageExts))
-		return &discordgo.MessageEmbed{
-			Title:       fmt.Sprintf("%s — Error", session.Code),
-			Description: "Invalid page number",
-			Color:       0xFF0000,
-		}
+	if !ensureNSFW(s, i) {
+		return
 	}
 
-	imgURL := fmt.Sprintf("https://i.nhentai.net/galleries/%s/%d.%s",
-		session.MediaID, page+1, session.PageExts[page])
-
-	log.Printf("Reader embed - Code: %s, Page: %d/%d, URL: %s",
-		session.Code, page+1, session.Total, imgURL)
-
-	return &discordgo.MessageEmbed{
-		Title: fmt.Sprintf("%s — Page %d/%d", session.Code, page+1, session.Total),
-		Image: &discordgo.MessageEmbedImage{URL: imgURL},
-		Color: 0x8A2BE2,
+	switch action {
+	case actionAllTags:
+		showAllTags(s, i, code)
+	case actionSimilar:
+		showSimilar(s, i, code)
 	}
 }
 
-// updateReader updates the current page
-func updateReader(s *discordgo.Session, session *ReadSession, msgID string) {
-	if session.Current < 0 || session.Current >= len(session.PageExts) {
+// showAllTags replies with every tag of a gallery, visible only to the user who asked
+func showAllTags(s discord.Client, i *discordgo.InteractionCreate, code string) {
+	// the API may retry for longer than Discord waits for a response
+	if err := discord.AcknowledgeEphemeral(s, i); err != nil {
+		log.Printf("Failed to acknowledge interaction: %v", err)
 		return
 	}
 
-	embed := buildReaderEmbed(session, session.Current)
-	if _, err := s.ChannelMessageEditEmbed(session.ChannelID, msgID, embed); err != nil {
-		log.Printf("Failed to update reader: %v", err)
+	doujin, err := DefaultClient.Gallery(context.Background(), code)
+	if err != nil {
+		editResponse(s, i, describeError(err), nil)
+		return
 	}
-}
 
-// addNavigationReactions adds navigation buttons
-func addNavigationReactions(s *discordgo.Session, msg *discordgo.Message) {
-	reactions := []string{"⬅️", "⏹️", "➡️"}
-	for _, emoji := range reactions {
-		s.MessageReactionAdd(msg.ChannelID, msg.ID, emoji)
+	embeds := []*discordgo.MessageEmbed{
+		{
+			Title:  fmt.Sprintf("All tags of %s", doujin.Title.Pretty),
+			URL:    DefaultClient.GalleryURL(code),
+			Color:  0x8A2BE2,
+			Fields: tagFields(doujin, 0),
+		},
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &embeds}); err != nil {
+		log.Printf("Failed to show all tags: %v", err)
 	}
 }
 
 // createSession creates a new session from doujin data
-func createSession(doujin *DoujinData, code, channelID, ownerID string) *ReadSession {
+func createSession(doujin *DoujinData, code, guildID, channelID, ownerID string) *ReadSession {
 	pageExts := make([]string, len(doujin.Images.Pages))
 	for i, page := range doujin.Images.Pages {
 		pageExts[i] = getExtension(page.Type)
 	}
 
+	now := time.Now()
 	return &ReadSession{
 		OwnerID:   ownerID,
 		MediaID:   doujin.MediaID,
 		PageExts:  pageExts,
 		Total:     doujin.NumPages,
+		GuildID:   guildID,
 		ChannelID: channelID,
 		Code:      code,
+		Title:     doujin.Title.Pretty,
+		Tags:      tagNames(doujin),
+		CreatedAt: now,
+		LastUsed:  now,
 	}
 }
 
 // storeSession stores a session in the original messages map
 func storeSession(msgID string, session *ReadSession) {
+	session.MessageID = msgID
+
 	sessionMutex.Lock()
 	originalMessages[msgID] = session
 	sessionMutex.Unlock()
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-// Interaction helpers
-func acknowledgeInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) error {
-	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-	})
-}
-
-func respondError(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: content,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-}
-
-func sendFollowup(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+func sendFollowup(s discord.Client, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) (*discordgo.Message, error) {
 	if i == nil || i.Interaction == nil {
 		return nil, fmt.Errorf("invalid interaction object")
 	}
 
 	// Note: The second parameter is 'wait' - true means wait for the message object to be returned
 	msg, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: "🔞 NSFW Content",
-		Embeds:  []*discordgo.MessageEmbed{embed},
+		Content:    "🔞 NSFW Content",
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
 	})
 
 	if err != nil {
@@ -390,10 +485,3 @@ func sendFollowup(s *discordgo.Session, i *discordgo.InteractionCreate, embed *d
 
 	return msg, nil
 }
-
-func sendFollowupError(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
-	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: content,
-		Flags:   discordgo.MessageFlagsEphemeral,
-	})
-}
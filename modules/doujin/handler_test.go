@@ -0,0 +1,204 @@
+package doujin
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+// useClient makes the handlers and the downloader use c and a temporary page cache
+func useClient(t *testing.T, c *Client) {
+	t.Helper()
+	client, downloaderClient, cache := DefaultClient, DefaultDownloader.Client, Cache
+	DefaultClient, DefaultDownloader.Client = c, c
+	Cache = NewPageCache(t.TempDir(), 0)
+	t.Cleanup(func() {
+		DefaultClient, DefaultDownloader.Client, Cache = client, downloaderClient, cache
+	})
+}
+
+// galleryServer serves galleryJSON and its images
+func galleryServer(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/api/gallery/177013":
+		w.Write([]byte(galleryJSON))
+	case strings.HasPrefix(r.URL.Path, "/i/galleries/987654/"):
+		w.Write([]byte("image"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func doujinCommand(channelID string, sub *discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "i-command",
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   "g1",
+		ChannelID: channelID,
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "u1"}},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name:    "doujin",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{sub},
+		},
+	}}
+}
+
+func infoSubcommand(code string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "info",
+		Type: discordgo.ApplicationCommandOptionSubCommand,
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "code", Type: discordgo.ApplicationCommandOptionString, Value: code},
+		},
+	}
+}
+
+func TestInfoCommand(t *testing.T) {
+	r := testRecorder(t)
+	useClient(t, testClient(t, galleryServer))
+
+	handleCommand(r, doujinCommand("c1", infoSubcommand("177013")))
+
+	responds := r.Calls("InteractionRespond")
+	if len(responds) != 1 || responds[0].Args[1].(*discordgo.InteractionResponse).Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Fatalf("interaction responses = %+v, want one acknowledgement", responds)
+	}
+	followups := r.Calls("FollowupMessageCreate")
+	if len(followups) == 0 {
+		t.Fatal("no info followup")
+	}
+	params := followups[0].Args[2].(*discordgo.WebhookParams)
+	if len(params.Embeds) != 1 || params.Embeds[0].Title != "Example" {
+		t.Errorf("info embeds = %+v", params.Embeds)
+	}
+	reactions := r.Calls("MessageReactionAdd")
+	if len(reactions) != 1 || reactions[0].Args[2] != "📖" {
+		t.Errorf("reactions = %+v, want the reader reaction", reactions)
+	}
+
+	// the info message can open a reader for the downloaded gallery
+	msgID := reactions[0].Args[1].(string)
+	t.Cleanup(func() {
+		sessionMutex.Lock()
+		delete(originalMessages, msgID)
+		sessionMutex.Unlock()
+	})
+	sessionMutex.RLock()
+	info := originalMessages[msgID]
+	sessionMutex.RUnlock()
+	if info == nil || info.Code != "177013" || info.Total != 2 || info.OwnerID != "u1" {
+		t.Errorf("info session = %+v", info)
+	}
+	if _, ok := Cache.PagePath("177013", 0, "jpg"); !ok {
+		t.Error("gallery was not downloaded into the page cache")
+	}
+}
+
+func TestInfoCommandRejectsInvalidCodes(t *testing.T) {
+	r := testRecorder(t)
+	useClient(t, testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	}))
+
+	handleCommand(r, doujinCommand("c1", infoSubcommand("../177013")))
+
+	content, _ := lastResponse(t, r)
+	if content != describeError(ErrInvalidCode) {
+		t.Errorf("response = %q", content)
+	}
+	if calls := r.Calls("FollowupMessageCreate"); len(calls) > 0 {
+		t.Errorf("sent %d followups for an invalid code", len(calls))
+	}
+}
+
+func TestInfoCommandReportsMissingGalleries(t *testing.T) {
+	r := testRecorder(t)
+	useClient(t, testClient(t, http.NotFound))
+
+	handleCommand(r, doujinCommand("c1", infoSubcommand("1")))
+
+	followups := r.Calls("FollowupMessageCreate")
+	if len(followups) != 1 {
+		t.Fatalf("got %d followups, want 1", len(followups))
+	}
+	params := followups[0].Args[2].(*discordgo.WebhookParams)
+	if !strings.Contains(params.Content, "not found") || params.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("followup = %q with flags %d, want an ephemeral not found error", params.Content, params.Flags)
+	}
+}
+
+func TestCommandRequiresNSFWChannel(t *testing.T) {
+	r := testRecorder(t)
+	r.Channels["c2"] = &discordgo.Channel{ID: "c2", GuildID: "g1", Type: discordgo.ChannelTypeGuildText}
+	useClient(t, testClient(t, galleryServer))
+
+	handleCommand(r, doujinCommand("c2", infoSubcommand("177013")))
+
+	content, _ := lastResponse(t, r)
+	if !strings.Contains(content, "NSFW") {
+		t.Errorf("response = %q, want an NSFW refusal", content)
+	}
+	if calls := r.Calls("FollowupMessageCreate", "MessageReactionAdd"); len(calls) > 0 {
+		t.Errorf("showed a gallery outside an NSFW channel: %+v", calls)
+	}
+}
+
+func TestShowAllTagsAcknowledgesFirst(t *testing.T) {
+	r := testRecorder(t)
+	useClient(t, testClient(t, galleryServer))
+
+	handleInfoComponent(r, componentClick("u1", infoPrefix+":"+actionAllTags+":177013"))
+
+	calls := r.Calls()
+	var methods []string
+	for _, c := range calls {
+		if c.Method != "Channel" {
+			methods = append(methods, c.Method)
+		}
+	}
+	if len(methods) != 2 || methods[0] != "InteractionRespond" || methods[1] != "InteractionResponseEdit" {
+		t.Fatalf("calls = %v, want an acknowledgement and an edit", methods)
+	}
+	resp := r.Calls("InteractionRespond")[0].Args[1].(*discordgo.InteractionResponse)
+	if resp.Type != discordgo.InteractionResponseDeferredChannelMessageWithSource || resp.Data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("acknowledgement = %+v, want an ephemeral deferred message", resp)
+	}
+	edit := r.Calls("InteractionResponseEdit")[0].Args[1].(*discordgo.WebhookEdit)
+	if edit.Embeds == nil || len(*edit.Embeds) != 1 || (*edit.Embeds)[0].Title != "All tags of Example" {
+		t.Errorf("edit = %+v", edit)
+	}
+}
+
+func TestOpenClicksShareTheDownloadLimits(t *testing.T) {
+	r := testRecorder(t)
+	handled := 0
+	h := discord.RateLimitNamed(DoujinLimits, limitName)(func(discord.Client, *discordgo.InteractionCreate) { handled++ })
+
+	for _, customID := range []string{"search:open:s1", "carousel:open:c1", "library:info"} {
+		h(r, componentClick("u1", customID, "177013"))
+	}
+	if handled != downloadLimits.PerUser.Burst {
+		t.Fatalf("handled %d opens, want %d", handled, downloadLimits.PerUser.Burst)
+	}
+
+	h(r, componentClick("u1", "carousel:open:c1"))
+	if content, _ := lastResponse(t, r); handled != 3 || !strings.Contains(content, "too often") {
+		t.Errorf("repeated open was not rejected, reply %q", content)
+	}
+	h(r, doujinCommand("c1", infoSubcommand("177013")))
+	if handled != 3 {
+		t.Error("/doujin info ran after the opens used up the download limits")
+	}
+
+	// browsing the results is not held up
+	for range 10 {
+		h(r, componentClick("u1", "carousel:next:c1"))
+	}
+	if handled != 13 {
+		t.Errorf("handled %d page turns, want 10", handled-3)
+	}
+}
@@ -0,0 +1,350 @@
+package doujin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+	"test/modules/storage"
+)
+
+// maxHistory is the number of galleries remembered per user
+const maxHistory = 25
+
+// LibraryEntry is a gallery in a user's favourites or reading history
+type LibraryEntry struct {
+	Code      string    `json:"code"`
+	Title     string    `json:"title"`
+	LastPage  int       `json:"last_page"` // zero-based
+	Total     int       `json:"total"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// userLibrary holds the favourites and reading history of a user, most recent first
+type userLibrary struct {
+	Favorites []LibraryEntry `json:"favorites"`
+	History   []LibraryEntry `json:"history"`
+}
+
+var (
+	libraryMutex sync.Mutex
+	libraries    = make(map[string]*userLibrary)
+	// historyDirty is set while page turns have not been saved yet
+	historyDirty bool
+	historyTimer *time.Timer
+)
+
+// HistorySaveDelay is how long page turns are collected before the reading
+// history is saved, so turning pages does not rewrite the library every time
+var HistorySaveDelay = 30 * time.Second
+
+// Library component custom IDs have the form "library:<action>"; the
+// messages are ephemeral, so only their owner can use them
+const libraryPrefix = "library"
+
+const (
+	actionResume   = "resume"
+	actionOpenInfo = "info"
+)
+
+func libraryPath() string {
+	return storage.Path("doujin_library.json")
+}
+
+// loadLibraries reads the persisted favourites and histories
+func loadLibraries() {
+	libraryMutex.Lock()
+	defer libraryMutex.Unlock()
+
+	if err := storage.Load(libraryPath(), &libraries); err != nil {
+		log.Printf("Failed to load doujin libraries: %v", err)
+	}
+	if libraries == nil {
+		libraries = make(map[string]*userLibrary)
+	}
+}
+
+// saveLibrariesLocked persists all libraries, libraryMutex must be held
+func saveLibrariesLocked() error {
+	if err := storage.Save(libraryPath(), libraries); err != nil {
+		return err
+	}
+	historyDirty = false
+	return nil
+}
+
+// libraryLocked returns the library of a user, creating it if needed
+func libraryLocked(userID string) *userLibrary {
+	lib, ok := libraries[userID]
+	if !ok {
+		lib = &userLibrary{}
+		libraries[userID] = lib
+	}
+	return lib
+}
+
+// recordHistory moves the session's gallery to the top of the owner's history
+// and remembers the page they are on
+func recordHistory(session *ReadSession) {
+	libraryMutex.Lock()
+	defer libraryMutex.Unlock()
+
+	updateHistoryLocked(session)
+	if err := saveLibrariesLocked(); err != nil {
+		log.Printf("Failed to save reading history: %v", err)
+	}
+}
+
+// recordPage remembers the page of a session like recordHistory, but only
+// saves within HistorySaveDelay
+func recordPage(session *ReadSession) {
+	libraryMutex.Lock()
+	defer libraryMutex.Unlock()
+
+	updateHistoryLocked(session)
+	historyDirty = true
+	if historyTimer == nil {
+		historyTimer = time.AfterFunc(HistorySaveDelay, func() {
+			libraryMutex.Lock()
+			historyTimer = nil
+			libraryMutex.Unlock()
+			SaveHistory()
+		})
+	}
+}
+
+// SaveHistory saves page turns that are waiting for HistorySaveDelay. The
+// bot calls it before exiting
+func SaveHistory() {
+	libraryMutex.Lock()
+	defer libraryMutex.Unlock()
+
+	if !historyDirty {
+		return
+	}
+	if err := saveLibrariesLocked(); err != nil {
+		log.Printf("Failed to save reading history: %v", err)
+	}
+}
+
+// updateHistoryLocked puts the session's gallery at the top of the owner's
+// history, libraryMutex must be held
+func updateHistoryLocked(session *ReadSession) {
+	lib := libraryLocked(session.OwnerID)
+	lib.History = slices.DeleteFunc(lib.History, func(e LibraryEntry) bool { return e.Code == session.Code })
+	lib.History = slices.Insert(lib.History, 0, LibraryEntry{
+		Code:      session.Code,
+		Title:     session.Title,
+		LastPage:  session.Current,
+		Total:     session.Total,
+		UpdatedAt: time.Now(),
+	})
+	if len(lib.History) > maxHistory {
+		lib.History = lib.History[:maxHistory]
+	}
+}
+
+// lastReadPage returns the page a user stopped reading a gallery at
+func lastReadPage(userID, code string) int {
+	libraryMutex.Lock()
+	defer libraryMutex.Unlock()
+
+	if lib, ok := libraries[userID]; ok {
+		for _, entry := range lib.History {
+			if entry.Code == code {
+				return entry.LastPage
+			}
+		}
+	}
+	return 0
+}
+
+// handleFavorite processes /doujin favorite add|remove|list
+func handleFavorite(s discord.Client, i *discordgo.InteractionCreate, group *discordgo.ApplicationCommandInteractionDataOption) {
+	if len(group.Options) == 0 {
+		discord.RespondEphemeral(s, i, "❌ Please choose a subcommand")
+		return
+	}
+	sub := group.Options[0]
+	userID := discord.UserID(i)
+	code := optionString(sub, "code")
+
+	switch sub.Name {
+	case "add":
+		// the API may retry for longer than Discord waits for a response
+		if err := discord.AcknowledgeEphemeral(s, i); err != nil {
+			log.Printf("Failed to acknowledge interaction: %v", err)
+			return
+		}
+		doujin, err := DefaultClient.Gallery(context.Background(), code)
+		if err != nil {
+			editResponse(s, i, describeError(err), nil)
+			return
+		}
+		entry := LibraryEntry{Code: code, Title: doujin.Title.Pretty, Total: doujin.NumPages, UpdatedAt: time.Now()}
+		editResponse(s, i, updateFavorites(userID, func(lib *userLibrary) string {
+			if slices.ContainsFunc(lib.Favorites, func(e LibraryEntry) bool { return e.Code == code }) {
+				return fmt.Sprintf("`%s` is already in your favourites", code)
+			}
+			lib.Favorites = slices.Insert(lib.Favorites, 0, entry)
+			return fmt.Sprintf("⭐ Added **%s** (`%s`) to your favourites", entry.Title, code)
+		}), nil)
+	case "remove":
+		discord.RespondEphemeral(s, i, updateFavorites(userID, func(lib *userLibrary) string {
+			before := len(lib.Favorites)
+			lib.Favorites = slices.DeleteFunc(lib.Favorites, func(e LibraryEntry) bool { return e.Code == code })
+			if len(lib.Favorites) == before {
+				return fmt.Sprintf("`%s` is not in your favourites", code)
+			}
+			return fmt.Sprintf("✅ Removed `%s` from your favourites", code)
+		}))
+	case "list":
+		libraryMutex.Lock()
+		var favorites []LibraryEntry
+		if lib, ok := libraries[userID]; ok {
+			favorites = slices.Clone(lib.Favorites)
+		}
+		libraryMutex.Unlock()
+
+		respondLibrary(s, i, "⭐ Your favourites", favorites, actionOpenInfo, "Open a favourite")
+	}
+}
+
+// updateFavorites applies change to a user's library, saves it and returns
+// the message for the user
+func updateFavorites(userID string, change func(lib *userLibrary) string) string {
+	libraryMutex.Lock()
+	message := change(libraryLocked(userID))
+	err := saveLibrariesLocked()
+	libraryMutex.Unlock()
+
+	if err != nil {
+		log.Printf("Failed to save favourites: %v", err)
+		message += "\n⚠️ The change could not be saved and will be lost on restart"
+	}
+	return message
+}
+
+// handleHistory processes /doujin history
+func handleHistory(s discord.Client, i *discordgo.InteractionCreate) {
+	libraryMutex.Lock()
+	var history []LibraryEntry
+	if lib, ok := libraries[discord.UserID(i)]; ok {
+		history = slices.Clone(lib.History)
+	}
+	libraryMutex.Unlock()
+
+	respondLibrary(s, i, "📚 Your reading history", history, actionResume, "Resume reading")
+}
+
+// respondLibrary shows a list of entries with a select menu performing action
+func respondLibrary(s discord.Client, i *discordgo.InteractionCreate, title string, entries []LibraryEntry, action, placeholder string) {
+	if len(entries) == 0 {
+		discord.RespondEphemeral(s, i, title+" is empty")
+		return
+	}
+
+	var lines []string
+	options := make([]discordgo.SelectMenuOption, 0, maxSelectOptions)
+	for _, entry := range entries {
+		progress := fmt.Sprintf("page %d/%d", entry.LastPage+1, entry.Total)
+		lines = append(lines, fmt.Sprintf("`%s` %s — %s, <t:%d:R>", entry.Code, entry.Title, progress, entry.UpdatedAt.Unix()))
+		if len(options) < maxSelectOptions {
+			options = append(options, discordgo.SelectMenuOption{
+				Label:       truncate(entry.Title, 100),
+				Value:       entry.Code,
+				Description: fmt.Sprintf("%s · %s", entry.Code, progress),
+			})
+		}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       title,
+					Description: truncate(strings.Join(lines, "\n"), 4000),
+					Color:       0x8A2BE2,
+				},
+			},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.SelectMenu{
+							CustomID:    libraryPrefix + ":" + action,
+							Placeholder: placeholder,
+							Options:     options,
+						},
+					},
+				},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to show library: %v", err)
+	}
+}
+
+// handleLibraryComponent processes the favourites and history select menus
+func handleLibraryComponent(s discord.Client, i *discordgo.InteractionCreate) {
+	if i == nil || i.Interaction == nil || i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+
+	action, ok := strings.CutPrefix(i.MessageComponentData().CustomID, libraryPrefix+":")
+	values := i.MessageComponentData().Values
+	if !ok || len(values) == 0 {
+		return
+	}
+	code := values[0]
+
+	if !ensureNSFW(s, i) {
+		return
+	}
+
+	switch action {
+	case actionOpenInfo:
+		if err := discord.Acknowledge(s, i); err != nil {
+			log.Printf("Failed to acknowledge interaction: %v", err)
+			return
+		}
+		showInfo(s, i, code)
+	case actionResume:
+		resumeReading(s, i, code)
+	}
+}
+
+// resumeReading opens a reader at the page the user stopped at
+func resumeReading(s discord.Client, i *discordgo.InteractionCreate, code string) {
+	// the API may retry for longer than Discord waits for a response
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}); err != nil {
+		log.Printf("Failed to acknowledge interaction: %v", err)
+		return
+	}
+
+	doujin, err := DefaultClient.Gallery(context.Background(), code)
+	if err != nil {
+		discord.FollowupEphemeral(s, i, describeError(err))
+		return
+	}
+
+	userID := discord.UserID(i)
+	base := createSession(doujin, code, i.GuildID, i.ChannelID, userID)
+	if blocked := blockedTags(i.GuildID, userID, base.Tags); len(blocked) > 0 {
+		discord.FollowupEphemeral(s, i, fmt.Sprintf("🚫 This gallery contains blocked tags: %s", strings.Join(blocked, ", ")))
+		return
+	}
+
+	startReader(s, base, userID, lastReadPage(userID, code))
+}
@@ -0,0 +1,128 @@
+package doujin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/storage"
+)
+
+func TestFavoriteAddAcknowledgesFirst(t *testing.T) {
+	r := testRecorder(t)
+	useClient(t, testClient(t, galleryServer))
+	t.Cleanup(func() {
+		libraryMutex.Lock()
+		delete(libraries, "u1")
+		libraryMutex.Unlock()
+	})
+
+	handleCommand(r, doujinCommand("c1", &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "favorite",
+		Type: discordgo.ApplicationCommandOptionSubCommandGroup,
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "add", Type: discordgo.ApplicationCommandOptionSubCommand, Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "code", Type: discordgo.ApplicationCommandOptionString, Value: "177013"},
+			}},
+		},
+	}))
+
+	calls := r.Calls("InteractionRespond", "InteractionResponseEdit")
+	if len(calls) != 2 || calls[0].Method != "InteractionRespond" {
+		t.Fatalf("calls = %+v, want an acknowledgement and an edit", calls)
+	}
+	if resp := calls[0].Args[1].(*discordgo.InteractionResponse); resp.Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Errorf("first response type %d, want a deferred message", resp.Type)
+	}
+	if edit := calls[1].Args[1].(*discordgo.WebhookEdit); !strings.Contains(*edit.Content, "Added **Example**") {
+		t.Errorf("reply = %q", *edit.Content)
+	}
+}
+
+func TestResumeReadingAcknowledgesFirst(t *testing.T) {
+	r := testRecorder(t)
+	useClient(t, testClient(t, galleryServer))
+	t.Cleanup(func() {
+		libraryMutex.Lock()
+		delete(libraries, "u1")
+		libraryMutex.Unlock()
+	})
+
+	handleLibraryComponent(r, componentClick("u1", libraryPrefix+":"+actionResume, "177013"))
+
+	calls := r.Calls("InteractionRespond", "ChannelMessageSendComplex")
+	if len(calls) != 2 || calls[0].Method != "InteractionRespond" || calls[1].Method != "ChannelMessageSendComplex" {
+		t.Fatalf("calls = %+v, want an acknowledgement and the reader", calls)
+	}
+	if resp := calls[0].Args[1].(*discordgo.InteractionResponse); resp.Type != discordgo.InteractionResponseDeferredMessageUpdate {
+		t.Errorf("first response type %d, want a deferred update", resp.Type)
+	}
+	t.Cleanup(func() {
+		sessionMutex.Lock()
+		for id, session := range activeReaders {
+			if session.OwnerID == "u1" {
+				delete(activeReaders, id)
+			}
+		}
+		sessionMutex.Unlock()
+	})
+}
+
+// savedPage returns the last page of a gallery in the persisted history of a user
+func savedPage(t *testing.T, userID, code string) int {
+	t.Helper()
+	var saved map[string]*userLibrary
+	if err := storage.Load(libraryPath(), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if lib, ok := saved[userID]; ok {
+		for _, entry := range lib.History {
+			if entry.Code == code {
+				return entry.LastPage
+			}
+		}
+	}
+	return -1
+}
+
+func TestPageTurnsAreSavedLater(t *testing.T) {
+	r := testRecorder(t)
+	testInfoMessage(t, "info-history")
+	delay := HistorySaveDelay
+	HistorySaveDelay = time.Hour
+	t.Cleanup(func() {
+		HistorySaveDelay = delay
+		libraryMutex.Lock()
+		delete(libraries, "reader-u1")
+		if historyTimer != nil {
+			historyTimer.Stop()
+			historyTimer = nil
+		}
+		libraryMutex.Unlock()
+	})
+
+	handleReaction(r, reactionAdd("reader-u1", "info-history"))
+	reader := readerOf("reader-u1", "info-history")
+	if reader == nil {
+		t.Fatal("no reader opened")
+	}
+	if page := savedPage(t, "reader-u1", "177013"); page != 0 {
+		t.Fatalf("saved page %d when opening, want 0", page)
+	}
+
+	handleReaderComponent(r, componentClick("reader-u1", readerID(actionNext, reader)))
+	handleReaderComponent(r, componentClick("reader-u1", readerID(actionNext, reader)))
+	if page := savedPage(t, "reader-u1", "177013"); page != 0 {
+		t.Errorf("page turns were saved right away, saved page %d", page)
+	}
+	if page := lastReadPage("reader-u1", "177013"); page != 2 {
+		t.Errorf("remembered page %d, want 2", page)
+	}
+
+	handleReaderComponent(r, componentClick("reader-u1", readerID(actionStop, reader)))
+	if page := savedPage(t, "reader-u1", "177013"); page != 2 {
+		t.Errorf("saved page %d after closing, want 2", page)
+	}
+}
@@ -0,0 +1,64 @@
+package doujin
+
+import (
+	"errors"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+var (
+	// AllowNonNSFWChannels lets the doujin module answer in channels that are not age-restricted
+	AllowNonNSFWChannels = false
+	// AllowDMs lets the doujin module answer in direct messages
+	AllowDMs = false
+)
+
+var (
+	errNotNSFW    = errors.New("🔞 This command can only be used in age-restricted (NSFW) channels")
+	errDMDisabled = errors.New("🔞 This command can not be used in direct messages")
+)
+
+// ChannelLookup resolves a channel by its ID. discord.Client and
+// *discordgo.State satisfy it, which lets the NSFW check run against a fake state.
+type ChannelLookup interface {
+	Channel(channelID string) (*discordgo.Channel, error)
+}
+
+// checkNSFW returns an error with a message for the user if NSFW content may
+// not be shown in a channel. Threads inherit the flag of their parent channel.
+func checkNSFW(channels ChannelLookup, guildID, channelID string) error {
+	if guildID == "" {
+		if AllowDMs {
+			return nil
+		}
+		return errDMDisabled
+	}
+	if AllowNonNSFWChannels {
+		return nil
+	}
+
+	channel, err := channels.Channel(channelID)
+	if err != nil {
+		return errNotNSFW
+	}
+	if channel.IsThread() {
+		if channel, err = channels.Channel(channel.ParentID); err != nil {
+			return errNotNSFW
+		}
+	}
+	if !channel.NSFW {
+		return errNotNSFW
+	}
+	return nil
+}
+
+// ensureNSFW replies to the interaction and returns false if NSFW content may not be shown
+func ensureNSFW(s discord.Client, i *discordgo.InteractionCreate) bool {
+	if err := checkNSFW(s, i.GuildID, i.ChannelID); err != nil {
+		discord.RespondEphemeral(s, i, err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package doujin
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// nsfwState returns a state with an NSFW and a regular text channel, each
+// with a thread
+func nsfwState(t *testing.T) *discordgo.State {
+	t.Helper()
+	state := discordgo.NewState()
+	if err := state.GuildAdd(&discordgo.Guild{ID: "g1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*discordgo.Channel{
+		{ID: "nsfw", GuildID: "g1", Type: discordgo.ChannelTypeGuildText, NSFW: true},
+		{ID: "sfw", GuildID: "g1", Type: discordgo.ChannelTypeGuildText},
+		{ID: "nsfw-thread", GuildID: "g1", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "nsfw"},
+		{ID: "sfw-thread", GuildID: "g1", Type: discordgo.ChannelTypeGuildPublicThread, ParentID: "sfw"},
+		{ID: "orphan-thread", GuildID: "g1", Type: discordgo.ChannelTypeGuildPrivateThread, ParentID: "deleted"},
+	} {
+		if err := state.ChannelAdd(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return state
+}
+
+// withNSFWSettings restores the module settings after the test
+func withNSFWSettings(t *testing.T, nonNSFW, dms bool) {
+	t.Helper()
+	oldNonNSFW, oldDMs := AllowNonNSFWChannels, AllowDMs
+	AllowNonNSFWChannels, AllowDMs = nonNSFW, dms
+	t.Cleanup(func() { AllowNonNSFWChannels, AllowDMs = oldNonNSFW, oldDMs })
+}
+
+func TestCheckNSFW(t *testing.T) {
+	withNSFWSettings(t, false, false)
+	state := nsfwState(t)
+
+	tests := []struct {
+		guildID, channelID string
+		want               error
+	}{
+		{"g1", "nsfw", nil},
+		{"g1", "sfw", errNotNSFW},
+		{"g1", "nsfw-thread", nil},
+		{"g1", "sfw-thread", errNotNSFW},
+		{"g1", "orphan-thread", errNotNSFW},
+		{"g1", "unknown", errNotNSFW},
+		{"", "dm", errDMDisabled},
+	}
+	for _, tt := range tests {
+		if err := checkNSFW(state, tt.guildID, tt.channelID); err != tt.want {
+			t.Errorf("checkNSFW(%q, %q) = %v, want %v", tt.guildID, tt.channelID, err, tt.want)
+		}
+	}
+}
+
+func TestCheckNSFWSettings(t *testing.T) {
+	state := nsfwState(t)
+
+	withNSFWSettings(t, true, false)
+	if err := checkNSFW(state, "g1", "sfw"); err != nil {
+		t.Errorf("non-NSFW channels allowed: got %v", err)
+	}
+	if err := checkNSFW(state, "", "dm"); err != errDMDisabled {
+		t.Errorf("non-NSFW channels allowed, DM: got %v, want %v", err, errDMDisabled)
+	}
+
+	withNSFWSettings(t, false, true)
+	if err := checkNSFW(state, "", "dm"); err != nil {
+		t.Errorf("DMs allowed: got %v", err)
+	}
+	if err := checkNSFW(state, "g1", "sfw"); err != errNotNSFW {
+		t.Errorf("DMs allowed, non-NSFW channel: got %v, want %v", err, errNotNSFW)
+	}
+}
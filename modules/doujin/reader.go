@@ -0,0 +1,636 @@
+package doujin
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/artifact"
+	"test/modules/discord"
+)
+
+// Reader component custom IDs have the form "reader:<action>:<sessionID>"
+const readerPrefix = "reader"
+
+const (
+	actionFirst  = "first"
+	actionPrev   = "prev"
+	actionNext   = "next"
+	actionLast   = "last"
+	actionJump   = "jump"
+	actionJumpTo = "jumpto"
+	actionPage   = "page"
+	actionStop   = "stop"
+	actionMode   = "mode"
+	actionGrant  = "grant"
+	actionRevoke = "revoke"
+)
+
+// Reader modes decide who may turn pages and whether 📖 opens a new reader
+const (
+	modePrivate = "" // only the owner and granted users turn pages
+	modeShared  = "shared"
+	modeFollow  = "follow" // others follow the owner's reader instead of opening their own
+)
+
+// nextMode is the order the mode button cycles through
+var nextMode = map[string]string{
+	modePrivate: modeShared,
+	modeShared:  modeFollow,
+	modeFollow:  modePrivate,
+}
+
+// maxGrantedUsers is the number of users that can be picked at once in the grant menu
+const maxGrantedUsers = 5
+
+// maxSelectOptions is the Discord limit of options in a select menu
+const maxSelectOptions = 25
+
+// openReader opens a new reader session
+func openReader(s discord.Client, r *discordgo.MessageReactionAdd) {
+	sessionMutex.RLock()
+	original, exists := originalMessages[r.MessageID]
+	sessionMutex.RUnlock()
+
+	if !exists || original == nil || len(original.PageExts) == 0 {
+		return
+	}
+
+	if err := checkNSFW(s, r.GuildID, r.ChannelID); err != nil {
+		log.Printf("Refused to open reader in channel %s: %v", r.ChannelID, err)
+		s.MessageReactionRemove(original.ChannelID, r.MessageID, "📖", r.UserID)
+		return
+	}
+
+	if blocked := blockedTags(original.GuildID, r.UserID, original.Tags); len(blocked) > 0 {
+		log.Printf("Refused to open reader for %s: blocked tags %v", r.UserID, blocked)
+		s.MessageReactionRemove(original.ChannelID, r.MessageID, "📖", r.UserID)
+		return
+	}
+
+	// a shared reader of this message is read together instead, a followed
+	// one is watched while its owner turns the pages
+	if group := groupReader(r.MessageID); group != nil {
+		if group.Mode == modeFollow && group.OwnerID != r.UserID {
+			follow(s, group.ID, r.UserID)
+		}
+		log.Printf("User %s joined %s reader %s", r.UserID, group.Mode, group.ID)
+		s.MessageReactionRemove(original.ChannelID, r.MessageID, "📖", r.UserID)
+		return
+	}
+
+	if startReader(s, original, r.UserID, lastReadPage(r.UserID, original.Code)) == nil {
+		return
+	}
+
+	sessionMutex.Lock()
+	original.LastUsed = time.Now()
+	sessionMutex.Unlock()
+
+	s.MessageReactionRemove(original.ChannelID, r.MessageID, "📖", r.UserID)
+}
+
+// startReader sends a new reader message for ownerID at the given page,
+// copying the gallery from base, and records it in the reading history
+func startReader(s discord.Client, base *ReadSession, ownerID string, page int) *ReadSession {
+	newSession := newReader(base, ownerID, page)
+	if !sendReader(s, newSession) {
+		return nil
+	}
+
+	recordHistory(newSession)
+	return newSession
+}
+
+// newReader returns a reader session for ownerID at the given page, copying
+// the gallery from base
+func newReader(base *ReadSession, ownerID string, page int) *ReadSession {
+	if page < 0 || page >= base.Total {
+		page = 0
+	}
+
+	now := time.Now()
+	return &ReadSession{
+		ID:        newSessionID(),
+		OwnerID:   ownerID,
+		MediaID:   base.MediaID,
+		PageExts:  base.PageExts,
+		Current:   page,
+		Total:     base.Total,
+		GuildID:   base.GuildID,
+		ChannelID: base.ChannelID,
+		Code:      base.Code,
+		OriginID:  base.MessageID,
+		Title:     base.Title,
+		Tags:      base.Tags,
+		CreatedAt: now,
+		LastUsed:  now,
+	}
+}
+
+// sendReader sends the message of a new session and makes the session active
+func sendReader(s discord.Client, session *ReadSession) bool {
+	embed, files := buildReaderEmbed(session, session.Current)
+	msg, err := s.ChannelMessageSendComplex(session.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: buildReaderComponents(session),
+		Files:      files,
+	})
+	if err != nil || msg == nil {
+		log.Printf("Failed to send reader embed: %v", err)
+		return false
+	}
+	session.MessageID = msg.ID
+
+	sessionMutex.Lock()
+	activeReaders[session.ID] = session
+	sessionMutex.Unlock()
+	return true
+}
+
+// readerView returns a copy of a session taken under the lock, which can be
+// rendered while other interactions change the session
+func readerView(session *ReadSession) ReadSession {
+	sessionMutex.RLock()
+	defer sessionMutex.RUnlock()
+
+	view := *session
+	view.Allowed = slices.Clone(session.Allowed)
+	view.Followers = slices.Clone(session.Followers)
+	return view
+}
+
+// handleReaderComponent processes reader buttons, the page select menu and the jump modal
+func handleReaderComponent(s discord.Client, i *discordgo.InteractionCreate) {
+	customID, ok := readerCustomID(i)
+	if !ok {
+		return
+	}
+
+	parts := strings.SplitN(customID, ":", 3)
+	if len(parts) != 3 || parts[0] != readerPrefix {
+		return
+	}
+	action, sessionID := parts[1], parts[2]
+
+	sessionMutex.RLock()
+	session, exists := activeReaders[sessionID]
+	sessionMutex.RUnlock()
+
+	if !exists || session == nil {
+		discord.RespondEphemeral(s, i, "❌ This reader is no longer active")
+		return
+	}
+	view := readerView(session)
+	userID := discord.UserID(i)
+	switch action {
+	case actionStop, actionMode, actionGrant, actionRevoke:
+		if userID != view.OwnerID {
+			discord.RespondEphemeral(s, i, fmt.Sprintf("❌ Only <@%s> can manage this reader", view.OwnerID))
+			return
+		}
+	default:
+		if !canTurnPages(&view, userID) {
+			discord.RespondEphemeral(s, i, fmt.Sprintf("❌ Only <@%s> and users they allowed can turn the pages of this reader", view.OwnerID))
+			return
+		}
+	}
+
+	switch action {
+	case actionStop:
+		closeReader(s, i, session)
+		return
+	case actionMode, actionGrant, actionRevoke:
+		changeAccess(s, i, action, session)
+		return
+	case actionJump:
+		showJumpModal(s, i, &view)
+		return
+	}
+
+	sessionMutex.Lock()
+	page, err := targetPage(i, action, session)
+	if err == nil {
+		session.Current = page
+		session.LastUsed = time.Now()
+		view = *session
+		view.Allowed = slices.Clone(session.Allowed)
+		view.Followers = slices.Clone(session.Followers)
+	}
+	sessionMutex.Unlock()
+	if err != nil {
+		discord.RespondEphemeral(s, i, "❌ "+err.Error())
+		return
+	}
+
+	recordPage(&view)
+	updateReader(s, i, &view)
+}
+
+// canTurnPages reports whether a user may navigate a reader, given a view
+// returned by readerView
+func canTurnPages(view *ReadSession, userID string) bool {
+	return userID == view.OwnerID ||
+		view.Mode == modeShared ||
+		slices.Contains(view.Allowed, userID)
+}
+
+// groupReader returns a view of the active shared or followed reader opened
+// from an info message
+func groupReader(originID string) *ReadSession {
+	sessionMutex.RLock()
+	defer sessionMutex.RUnlock()
+
+	for _, session := range activeReaders {
+		if session.OriginID == originID && session.Mode != modePrivate {
+			view := *session
+			view.Allowed = slices.Clone(session.Allowed)
+			view.Followers = slices.Clone(session.Followers)
+			return &view
+		}
+	}
+	return nil
+}
+
+// follow subscribes a user to a follow mode reader and shows the new
+// follower count on its message
+func follow(s discord.Client, sessionID, userID string) {
+	sessionMutex.Lock()
+	session, ok := activeReaders[sessionID]
+	if !ok || session.Mode != modeFollow || slices.Contains(session.Followers, userID) {
+		sessionMutex.Unlock()
+		return
+	}
+	session.Followers = append(session.Followers, userID)
+	session.LastUsed = time.Now()
+	view := *session
+	view.Allowed = slices.Clone(session.Allowed)
+	view.Followers = slices.Clone(session.Followers)
+	sessionMutex.Unlock()
+
+	editReader(s, &view)
+}
+
+// editReader shows the current page of a view on its reader message outside
+// of an interaction
+func editReader(s discord.Client, view *ReadSession) {
+	embed, files := buildReaderEmbed(view, view.Current)
+	components := buildReaderComponents(view)
+	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:          view.MessageID,
+		Channel:     view.ChannelID,
+		Embeds:      &[]*discordgo.MessageEmbed{embed},
+		Components:  &components,
+		Files:       files,
+		Attachments: &[]*discordgo.MessageAttachment{},
+	}); err != nil {
+		log.Printf("Failed to update reader %s: %v", view.ID, err)
+	}
+}
+
+// changeAccess switches the reader mode or grants and revokes page control
+func changeAccess(s discord.Client, i *discordgo.InteractionCreate, action string, session *ReadSession) {
+	sessionMutex.Lock()
+	switch action {
+	case actionMode:
+		session.Mode = nextMode[session.Mode]
+	case actionGrant:
+		for _, userID := range i.MessageComponentData().Values {
+			if userID != session.OwnerID && !slices.Contains(session.Allowed, userID) {
+				session.Allowed = append(session.Allowed, userID)
+			}
+		}
+	case actionRevoke:
+		revoked := i.MessageComponentData().Values
+		session.Allowed = slices.DeleteFunc(session.Allowed, func(userID string) bool {
+			return slices.Contains(revoked, userID)
+		})
+	}
+	if session.Mode != modeFollow {
+		session.Followers = nil
+	}
+	session.LastUsed = time.Now()
+	view := *session
+	view.Allowed = slices.Clone(session.Allowed)
+	view.Followers = slices.Clone(session.Followers)
+	sessionMutex.Unlock()
+
+	updateReader(s, i, &view)
+}
+
+// readerCustomID returns the custom ID of a reader component or modal interaction
+func readerCustomID(i *discordgo.InteractionCreate) (string, bool) {
+	if i == nil || i.Interaction == nil {
+		return "", false
+	}
+
+	var customID string
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		customID = i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		customID = i.ModalSubmitData().CustomID
+	default:
+		return "", false
+	}
+
+	return customID, strings.HasPrefix(customID, readerPrefix+":")
+}
+
+// targetPage resolves the zero-based page an action navigates to. The caller
+// must hold sessionMutex.
+func targetPage(i *discordgo.InteractionCreate, action string, session *ReadSession) (int, error) {
+	switch action {
+	case actionFirst:
+		return 0, nil
+	case actionPrev:
+		return max(session.Current-1, 0), nil
+	case actionNext:
+		return min(session.Current+1, session.Total-1), nil
+	case actionLast:
+		return session.Total - 1, nil
+	case actionPage:
+		values := i.MessageComponentData().Values
+		if len(values) == 0 {
+			return 0, fmt.Errorf("no page selected")
+		}
+		return parsePage(values[0], session)
+	case actionJumpTo:
+		return parsePage(modalValue(i, "page"), session)
+	}
+	return 0, fmt.Errorf("unknown reader action %q", action)
+}
+
+// parsePage converts a one-based page number to a page index
+func parsePage(value string, session *ReadSession) (int, error) {
+	page, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || page < 1 || page > session.Total {
+		return 0, fmt.Errorf("page must be a number between 1 and %d", session.Total)
+	}
+	return page - 1, nil
+}
+
+// modalValue returns the value of the text input with the given custom ID
+func modalValue(i *discordgo.InteractionCreate, customID string) string {
+	for _, row := range i.ModalSubmitData().Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
+
+// showJumpModal asks the user for a page number
+func showJumpModal(s discord.Client, i *discordgo.InteractionCreate, session *ReadSession) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: readerID(actionJumpTo, session),
+			Title:    fmt.Sprintf("%s — Jump to page", session.Code),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "page",
+							Label:       fmt.Sprintf("Page (1-%d)", session.Total),
+							Style:       discordgo.TextInputShort,
+							Placeholder: strconv.Itoa(session.Current + 1),
+							Required:    true,
+							MaxLength:   len(strconv.Itoa(session.Total)),
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to show jump modal: %v", err)
+	}
+}
+
+// closeReader deletes the reader message and forgets the session
+func closeReader(s discord.Client, i *discordgo.InteractionCreate, session *ReadSession) {
+	sessionMutex.Lock()
+	delete(activeReaders, session.ID)
+	sessionMutex.Unlock()
+	SaveHistory()
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}); err != nil {
+		log.Printf("Failed to acknowledge reader close: %v", err)
+	}
+	if err := s.ChannelMessageDelete(session.ChannelID, session.MessageID); err != nil {
+		log.Printf("Failed to delete reader: %v", err)
+	}
+}
+
+// buildReaderEmbed creates a reader page embed from a view returned by
+// readerView. Pages found in the local cache are returned as a file to
+// attach, otherwise the embed links the remote image.
+func buildReaderEmbed(session *ReadSession, page int) (*discordgo.MessageEmbed, []*discordgo.File) {
+	if page < 0 || page >= len(session.PageExts) {
+		log.Printf("Invalid page index: %d (total pages: %d)", page, len(session.PageExts))
+		return &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("%s — Error", session.Code),
+			Description: "Invalid page number",
+			Color:       0xFF0000,
+		}, nil
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("%s — Page %d/%d", session.Code, page+1, session.Total),
+		Color:  0x8A2BE2,
+		Footer: &discordgo.MessageEmbedFooter{Text: readerAccess(session)},
+	}
+
+	if file := cachedPage(session, page); file != nil {
+		log.Printf("Reader embed - Code: %s, Page: %d/%d, cached file: %s",
+			session.Code, page+1, session.Total, file.Name)
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + file.Name}
+		return embed, []*discordgo.File{file}
+	}
+
+	imgURL := DefaultClient.PageURL(session.MediaID, page, session.PageExts[page])
+
+	log.Printf("Reader embed - Code: %s, Page: %d/%d, URL: %s",
+		session.Code, page+1, session.Total, imgURL)
+
+	embed.Image = &discordgo.MessageEmbedImage{URL: imgURL}
+	return embed, nil
+}
+
+// cachedPage loads a page from the local cache, returning nil if it is not
+// available or too large to upload
+func cachedPage(session *ReadSession, page int) *discordgo.File {
+	ext := session.PageExts[page]
+	path, ok := Cache.PagePath(session.Code, page, ext)
+	if !ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) > artifact.UploadLimit {
+		return nil
+	}
+
+	return &discordgo.File{
+		Name:        fmt.Sprintf("page_%03d.%s", page+1, ext),
+		ContentType: "image/" + strings.Replace(ext, "jpg", "jpeg", 1),
+		Reader:      bytes.NewReader(data),
+	}
+}
+
+// buildReaderComponents creates the navigation buttons and page select menu
+// from a view returned by readerView
+func buildReaderComponents(session *ReadSession) []discordgo.MessageComponent {
+	atStart := session.Current <= 0
+	atEnd := session.Current >= session.Total-1
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "⏮️"}, Style: discordgo.SecondaryButton, CustomID: readerID(actionFirst, session), Disabled: atStart},
+				discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "⬅️"}, Style: discordgo.PrimaryButton, CustomID: readerID(actionPrev, session), Disabled: atStart},
+				discordgo.Button{Label: "Jump", Style: discordgo.SecondaryButton, CustomID: readerID(actionJump, session)},
+				discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "➡️"}, Style: discordgo.PrimaryButton, CustomID: readerID(actionNext, session), Disabled: atEnd},
+				discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "⏭️"}, Style: discordgo.SecondaryButton, CustomID: readerID(actionLast, session), Disabled: atEnd},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    readerID(actionPage, session),
+					Placeholder: fmt.Sprintf("Page %d/%d", session.Current+1, session.Total),
+					Options:     pageOptions(session),
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Close", Emoji: &discordgo.ComponentEmoji{Name: "⏹️"}, Style: discordgo.DangerButton, CustomID: readerID(actionStop, session)},
+				discordgo.Button{Label: modeLabel(session.Mode), Emoji: &discordgo.ComponentEmoji{Name: "👥"}, Style: discordgo.SecondaryButton, CustomID: readerID(actionMode, session)},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:    discordgo.UserSelectMenu,
+					CustomID:    readerID(actionGrant, session),
+					Placeholder: "Allow users to turn pages",
+					MaxValues:   maxGrantedUsers,
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:    discordgo.UserSelectMenu,
+					CustomID:    readerID(actionRevoke, session),
+					Placeholder: "Take page control away from users",
+					MaxValues:   maxGrantedUsers,
+				},
+			},
+		},
+	}
+}
+
+// modeLabel describes a reader mode on the mode button
+func modeLabel(mode string) string {
+	switch mode {
+	case modeShared:
+		return "Mode: Shared"
+	case modeFollow:
+		return "Mode: Follow"
+	default:
+		return "Mode: Private"
+	}
+}
+
+// readerAccess describes who controls a reader, shown in the embed footer
+func readerAccess(session *ReadSession) string {
+	var text string
+	switch session.Mode {
+	case modeShared:
+		text = "Shared reading, anyone can turn pages"
+	case modeFollow:
+		text = "Follow mode, react with 📖 to read along with the owner"
+		if len(session.Followers) > 0 {
+			text += fmt.Sprintf(" · %d following", len(session.Followers))
+		}
+	default:
+		text = "Private reader"
+	}
+	if len(session.Allowed) > 0 && session.Mode != modeShared {
+		text += fmt.Sprintf(" · %d other user(s) can turn pages", len(session.Allowed))
+	}
+	return text
+}
+
+// pageOptions returns a window of select options around the current page,
+// since a select menu can hold at most 25 options
+func pageOptions(session *ReadSession) []discordgo.SelectMenuOption {
+	start := max(session.Current-maxSelectOptions/2, 0)
+	end := min(start+maxSelectOptions, session.Total)
+	start = max(end-maxSelectOptions, 0)
+
+	options := make([]discordgo.SelectMenuOption, 0, end-start)
+	for page := start; page < end; page++ {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:   fmt.Sprintf("Page %d", page+1),
+			Value:   strconv.Itoa(page + 1),
+			Default: page == session.Current,
+		})
+	}
+	return options
+}
+
+// updateReader shows the current page of a view returned by readerView on
+// the reader message
+func updateReader(s discord.Client, i *discordgo.InteractionCreate, session *ReadSession) {
+	if session.Current < 0 || session.Current >= len(session.PageExts) {
+		return
+	}
+
+	embed, files := buildReaderEmbed(session, session.Current)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: buildReaderComponents(session),
+			Files:      files,
+			// drop the previous page's attachment
+			Attachments: &[]*discordgo.MessageAttachment{},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to update reader: %v", err)
+	}
+}
+
+// readerID builds the custom ID of a reader component
+func readerID(action string, session *ReadSession) string {
+	return readerPrefix + ":" + action + ":" + session.ID
+}
+
+// newSessionID returns a short random identifier for a reader session
+func newSessionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,229 @@
+package doujin
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+	"test/modules/storage"
+)
+
+// testRecorder returns a Recorder with an NSFW channel c1 in guild g1 and
+// keeps data written by the handlers in a temporary directory
+func testRecorder(t *testing.T) *discord.Recorder {
+	t.Helper()
+	dir := storage.Dir
+	storage.Dir = t.TempDir()
+	t.Cleanup(func() { storage.Dir = dir })
+
+	r := discord.NewRecorder()
+	r.Channels["c1"] = &discordgo.Channel{ID: "c1", GuildID: "g1", Type: discordgo.ChannelTypeGuildText, NSFW: true}
+	return r
+}
+
+// testInfoMessage registers an info message readers can be opened from
+func testInfoMessage(t *testing.T, msgID string) *ReadSession {
+	t.Helper()
+	info := &ReadSession{
+		MessageID: msgID,
+		MediaID:   "987654",
+		PageExts:  []string{"jpg", "jpg", "png", "jpg"},
+		Total:     4,
+		GuildID:   "g1",
+		ChannelID: "c1",
+		Code:      "177013",
+		Title:     "Example",
+		LastUsed:  time.Now(),
+	}
+	sessionMutex.Lock()
+	originalMessages[msgID] = info
+	sessionMutex.Unlock()
+	t.Cleanup(func() {
+		sessionMutex.Lock()
+		delete(originalMessages, msgID)
+		for id, session := range activeReaders {
+			if session.OriginID == msgID {
+				delete(activeReaders, id)
+			}
+		}
+		sessionMutex.Unlock()
+	})
+	return info
+}
+
+func reactionAdd(userID, msgID string) *discordgo.MessageReactionAdd {
+	return &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		UserID:    userID,
+		MessageID: msgID,
+		ChannelID: "c1",
+		GuildID:   "g1",
+		Emoji:     discordgo.Emoji{Name: "📖"},
+	}}
+}
+
+func componentClick(userID, customID string, values ...string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "i-" + customID,
+		Type:      discordgo.InteractionMessageComponent,
+		GuildID:   "g1",
+		ChannelID: "c1",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: userID}},
+		Data:      discordgo.MessageComponentInteractionData{CustomID: customID, Values: values},
+	}}
+}
+
+// readerOf returns the active reader of a user opened from an info message
+func readerOf(userID, originID string) *ReadSession {
+	sessionMutex.RLock()
+	defer sessionMutex.RUnlock()
+	for _, session := range activeReaders {
+		if session.OwnerID == userID && session.OriginID == originID {
+			return session
+		}
+	}
+	return nil
+}
+
+// lastResponse returns the content and embed title of the last interaction response
+func lastResponse(t *testing.T, r *discord.Recorder) (string, string) {
+	t.Helper()
+	calls := r.Calls("InteractionRespond")
+	if len(calls) == 0 {
+		t.Fatal("no interaction response")
+	}
+	data := calls[len(calls)-1].Args[1].(*discordgo.InteractionResponse).Data
+	title := ""
+	if len(data.Embeds) > 0 {
+		title = data.Embeds[0].Title
+	}
+	return data.Content, title
+}
+
+func TestFollowModeSharesOwnerReader(t *testing.T) {
+	r := testRecorder(t)
+	testInfoMessage(t, "info1")
+
+	handleReaction(r, reactionAdd("owner", "info1"))
+	leader := readerOf("owner", "info1")
+	if leader == nil {
+		t.Fatal("no reader opened for the owner")
+	}
+
+	handleReaderComponent(r, componentClick("owner", readerID(actionMode, leader)))
+	handleReaderComponent(r, componentClick("owner", readerID(actionMode, leader)))
+	if mode := readerView(leader).Mode; mode != modeFollow {
+		t.Fatalf("mode is %q, want %q", mode, modeFollow)
+	}
+
+	// following subscribes to the owner's message instead of sending another
+	r.Reset()
+	handleReaction(r, reactionAdd("guest", "info1"))
+	if sends := r.Calls("ChannelMessageSendComplex"); len(sends) != 0 {
+		t.Fatalf("following sent %d reader message(s)", len(sends))
+	}
+	if readerOf("guest", "info1") != nil {
+		t.Fatal("guest got a reader of their own")
+	}
+	if followers := readerView(leader).Followers; !slices.Equal(followers, []string{"guest"}) {
+		t.Fatalf("followers are %v", followers)
+	}
+	edits := r.Calls("ChannelMessageEditComplex")
+	if len(edits) != 1 {
+		t.Fatalf("got %d reader edits, want 1", len(edits))
+	}
+	edit := edits[0].Args[0].(*discordgo.MessageEdit)
+	if edit.ID != leader.MessageID || !strings.Contains((*edit.Embeds)[0].Footer.Text, "1 following") {
+		t.Errorf("reader edit %s shows %q", edit.ID, (*edit.Embeds)[0].Footer.Text)
+	}
+
+	// only the owner turns the pages
+	handleReaderComponent(r, componentClick("guest", readerID(actionNext, leader)))
+	if content, _ := lastResponse(t, r); !strings.Contains(content, "Only <@owner>") {
+		t.Errorf("follower turned a page: %q", content)
+	}
+	handleReaderComponent(r, componentClick("owner", readerID(actionLast, leader)))
+	if _, title := lastResponse(t, r); !strings.Contains(title, "Page 4/4") {
+		t.Errorf("owner got %q", title)
+	}
+
+	// leaving follow mode forgets the followers
+	handleReaderComponent(r, componentClick("owner", readerID(actionMode, leader)))
+	if followers := readerView(leader).Followers; len(followers) != 0 {
+		t.Errorf("followers kept after follow mode was left: %v", followers)
+	}
+}
+
+func TestPrivateReaderRejectsOthers(t *testing.T) {
+	r := testRecorder(t)
+	testInfoMessage(t, "info2")
+
+	handleReaction(r, reactionAdd("owner2", "info2"))
+	session := readerOf("owner2", "info2")
+	if session == nil {
+		t.Fatal("no reader opened")
+	}
+
+	handleReaderComponent(r, componentClick("guest", readerID(actionNext, session)))
+	if content, _ := lastResponse(t, r); !strings.Contains(content, "Only <@owner2>") {
+		t.Errorf("guest turned a page: %q", content)
+	}
+
+	handleReaderComponent(r, componentClick("owner2", readerID(actionGrant, session), "guest"))
+	handleReaderComponent(r, componentClick("guest", readerID(actionNext, session)))
+	if _, title := lastResponse(t, r); !strings.Contains(title, "Page 2/4") {
+		t.Errorf("granted guest got %q", title)
+	}
+}
+
+func TestSharedReaderConcurrentClicks(t *testing.T) {
+	r := testRecorder(t)
+	testInfoMessage(t, "info3")
+
+	handleReaction(r, reactionAdd("owner", "info3"))
+	session := readerOf("owner", "info3")
+	if session == nil {
+		t.Fatal("no reader opened")
+	}
+	handleReaderComponent(r, componentClick("owner", readerID(actionMode, session)))
+
+	// run with -race: rendering must not read the session while it changes
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, action := range []string{actionNext, actionPrev, actionLast, actionFirst} {
+				handleReaderComponent(r, componentClick("guest", readerID(action, session)))
+			}
+			handleReaderComponent(r, componentClick("owner", readerID(actionGrant, session), "guest"))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestReactionsNeedTheCommandAccess(t *testing.T) {
+	r := testRecorder(t)
+	testInfoMessage(t, "info4")
+	router := discord.NewRouter(r)
+	registerRoutes(router)
+	router.Restrict(DoujinCommand[0], discord.Access{Roles: []string{"readers"}})
+
+	denied := reactionAdd("guest", "info4")
+	denied.Member = &discordgo.Member{User: &discordgo.User{ID: "guest"}, Roles: []string{"guests"}}
+	router.DispatchReaction(denied)
+	if readerOf("guest", "info4") != nil || len(r.Calls("ChannelMessageSendComplex")) > 0 {
+		t.Fatal("a reader was opened for a role without access to /doujin")
+	}
+
+	allowed := reactionAdd("reader", "info4")
+	allowed.Member = &discordgo.Member{User: &discordgo.User{ID: "reader"}, Roles: []string{"readers"}}
+	router.DispatchReaction(allowed)
+	if readerOf("reader", "info4") == nil {
+		t.Error("no reader was opened for a role with access to /doujin")
+	}
+}
@@ -0,0 +1,299 @@
+package doujin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+const (
+	sortRecent  = "recent"
+	sortPopular = "popular"
+)
+
+// Search component custom IDs have the form "search:<action>:<searchID>"
+const searchPrefix = "search"
+
+const (
+	actionSearchPrev = "prev"
+	actionSearchNext = "next"
+	actionSearchOpen = "open"
+)
+
+// searchState remembers a search so its result pages can be browsed
+type searchState struct {
+	ID       string
+	Query    string
+	Sort     string
+	Page     int
+	NumPages int
+	LastUsed time.Time
+}
+
+var searches = make(map[string]*searchState)
+
+// handleSearch processes /doujin search
+func handleSearch(s discord.Client, i *discordgo.InteractionCreate, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	query := buildSearchQuery(optionString(sub, "query"), splitTags(optionString(sub, "tags")), splitTags(optionString(sub, "exclude")))
+	if query == "" {
+		discord.RespondEphemeral(s, i, "❌ Please provide a query or tags to search for")
+		return
+	}
+
+	search := &searchState{
+		ID:       newSessionID(),
+		Query:    query,
+		Sort:     optionString(sub, "sort"),
+		Page:     1,
+		LastUsed: time.Now(),
+	}
+	for _, option := range sub.Options {
+		if option.Name == "page" {
+			search.Page = int(option.IntValue())
+		}
+	}
+
+	if err := discord.Acknowledge(s, i); err != nil {
+		log.Printf("Failed to acknowledge interaction: %v", err)
+		return
+	}
+
+	result, err := runSearch(*search)
+	if err != nil {
+		discord.FollowupEphemeral(s, i, describeError(err))
+		return
+	}
+	search.NumPages = result.NumPages
+	hideBlocked(i, result)
+
+	sessionMutex.Lock()
+	searches[search.ID] = search
+	sessionMutex.Unlock()
+
+	embeds := []*discordgo.MessageEmbed{buildSearchEmbed(search, result)}
+	components := buildSearchComponents(search, result)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &embeds,
+		Components: &components,
+	}); err != nil {
+		log.Printf("Failed to send search results: %v", err)
+	}
+}
+
+// buildSearchQuery combines free text with required and excluded tags
+// using the nhentai search syntax
+func buildSearchQuery(text string, tags, exclude []string) string {
+	parts := []string{}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	for _, tag := range tags {
+		parts = append(parts, fmt.Sprintf("tag:%q", tag))
+	}
+	for _, tag := range exclude {
+		parts = append(parts, fmt.Sprintf("-tag:%q", tag))
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitTags splits a comma separated list of tags
+func splitTags(list string) []string {
+	var tags []string
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// runSearch fetches the current page of a search
+func runSearch(search searchState) (*SearchResult, error) {
+	sort := ""
+	if search.Sort == sortPopular {
+		sort = sortPopular
+	}
+	return DefaultClient.Search(context.Background(), search.Query, sort, search.Page)
+}
+
+// hideBlocked removes the galleries with tags blocked for the user from a result page
+func hideBlocked(i *discordgo.InteractionCreate, result *SearchResult) {
+	result.Result = withoutBlocked(i.GuildID, discord.UserID(i), result.Result)
+}
+
+// withoutBlocked returns the galleries without tags blocked for a user in a server
+func withoutBlocked(guildID, userID string, galleries []*DoujinData) []*DoujinData {
+	var visible []*DoujinData
+	for _, doujin := range galleries {
+		if len(blockedTags(guildID, userID, tagNames(doujin))) == 0 {
+			visible = append(visible, doujin)
+		}
+	}
+	return visible
+}
+
+// buildSearchEmbed lists the galleries of a result page
+func buildSearchEmbed(search *searchState, result *SearchResult) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("🔍 %s", search.Query),
+		Color:  0x8A2BE2,
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d/%d", search.Page, max(search.NumPages, 1))},
+	}
+
+	if len(result.Result) == 0 {
+		embed.Description = "No galleries found"
+		return embed
+	}
+
+	var lines []string
+	for _, doujin := range result.Result {
+		line := fmt.Sprintf("`%d` %s (%dp)", doujin.ID, doujin.Title.Pretty, doujin.NumPages)
+		if len(strings.Join(lines, "\n"))+len(line) > 4000 {
+			break
+		}
+		lines = append(lines, line)
+	}
+	embed.Description = strings.Join(lines, "\n")
+	return embed
+}
+
+// buildSearchComponents creates the result select menu and page buttons
+func buildSearchComponents(search *searchState, result *SearchResult) []discordgo.MessageComponent {
+	var components []discordgo.MessageComponent
+
+	if len(result.Result) > 0 {
+		options := make([]discordgo.SelectMenuOption, 0, maxSelectOptions)
+		for _, doujin := range result.Result {
+			if len(options) == maxSelectOptions {
+				break
+			}
+			options = append(options, discordgo.SelectMenuOption{
+				Label:       truncate(doujin.Title.Pretty, 100),
+				Value:       strconv.Itoa(doujin.ID),
+				Description: fmt.Sprintf("%d · %d pages", doujin.ID, doujin.NumPages),
+			})
+		}
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    searchID(actionSearchOpen, search),
+					Placeholder: "Open a gallery",
+					Options:     options,
+				},
+			},
+		})
+	}
+
+	components = append(components, discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "⬅️"}, Style: discordgo.PrimaryButton, CustomID: searchID(actionSearchPrev, search), Disabled: search.Page <= 1},
+			discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "➡️"}, Style: discordgo.PrimaryButton, CustomID: searchID(actionSearchNext, search), Disabled: search.Page >= search.NumPages},
+		},
+	})
+
+	return components
+}
+
+// handleSearchComponent processes the search result page buttons and select menu
+func handleSearchComponent(s discord.Client, i *discordgo.InteractionCreate) {
+	if i == nil || i.Interaction == nil || i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+
+	parts := strings.SplitN(i.MessageComponentData().CustomID, ":", 3)
+	if len(parts) != 3 || parts[0] != searchPrefix {
+		return
+	}
+	action, searchID := parts[1], parts[2]
+
+	if !ensureNSFW(s, i) {
+		return
+	}
+
+	// the search is shared by everyone clicking the buttons, work on a copy
+	// and only write the page back under the lock
+	sessionMutex.Lock()
+	search, exists := searches[searchID]
+	var current searchState
+	if exists {
+		search.LastUsed = time.Now()
+		current = *search
+	}
+	sessionMutex.Unlock()
+
+	if !exists {
+		discord.RespondEphemeral(s, i, "❌ This search has expired, please search again")
+		return
+	}
+
+	switch action {
+	case actionSearchOpen:
+		values := i.MessageComponentData().Values
+		if len(values) == 0 {
+			return
+		}
+		if err := discord.Acknowledge(s, i); err != nil {
+			log.Printf("Failed to acknowledge interaction: %v", err)
+			return
+		}
+		showInfo(s, i, values[0])
+		return
+	case actionSearchPrev:
+		current.Page = max(current.Page-1, 1)
+	case actionSearchNext:
+		current.Page = min(current.Page+1, current.NumPages)
+	default:
+		return
+	}
+
+	// the API may retry for longer than Discord waits for a response
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}); err != nil {
+		log.Printf("Failed to acknowledge interaction: %v", err)
+		return
+	}
+
+	result, err := runSearch(current)
+	if err != nil {
+		discord.FollowupEphemeral(s, i, describeError(err))
+		return
+	}
+	current.NumPages = result.NumPages
+	hideBlocked(i, result)
+
+	sessionMutex.Lock()
+	search.Page = current.Page
+	search.NumPages = current.NumPages
+	sessionMutex.Unlock()
+
+	embeds := []*discordgo.MessageEmbed{buildSearchEmbed(&current, result)}
+	components := buildSearchComponents(&current, result)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &embeds,
+		Components: &components,
+	}); err != nil {
+		log.Printf("Failed to update search results: %v", err)
+	}
+}
+
+// searchID builds the custom ID of a search component
+func searchID(action string, search *searchState) string {
+	return searchPrefix + ":" + action + ":" + search.ID
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,96 @@
+package doujin
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+// SessionTTL is how long a reader or info message stays usable without interaction
+var SessionTTL = 30 * time.Minute
+
+// runSessionJanitor periodically expires idle sessions
+func runSessionJanitor(s discord.Client) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		expireSessions(s, time.Now().Add(-SessionTTL))
+	}
+}
+
+// expireSessions removes every session last used before cutoff and closes its message
+func expireSessions(s discord.Client, cutoff time.Time) {
+	var expiredReaders, expiredOriginals []*ReadSession
+	var originalIDs []string
+
+	sessionMutex.Lock()
+	for id, session := range activeReaders {
+		if session.LastUsed.Before(cutoff) {
+			view := *session
+			expiredReaders = append(expiredReaders, &view)
+			delete(activeReaders, id)
+		}
+	}
+	for id, search := range searches {
+		if search.LastUsed.Before(cutoff) {
+			delete(searches, id)
+		}
+	}
+	for id, carousel := range carousels {
+		if carousel.LastUsed.Before(cutoff) {
+			delete(carousels, id)
+		}
+	}
+	for msgID, session := range originalMessages {
+		if session.LastUsed.Before(cutoff) {
+			expiredOriginals = append(expiredOriginals, session)
+			originalIDs = append(originalIDs, msgID)
+			delete(originalMessages, msgID)
+		}
+	}
+	sessionMutex.Unlock()
+
+	for _, session := range expiredReaders {
+		closeExpiredReader(s, session)
+	}
+	if len(expiredReaders) > 0 {
+		SaveHistory()
+	}
+	for i, session := range expiredOriginals {
+		// without the reaction nobody can open a reader from this message anymore
+		if err := s.MessageReactionsRemoveEmoji(session.ChannelID, originalIDs[i], "📖"); err != nil {
+			log.Printf("Failed to remove reader reaction from %s: %v", originalIDs[i], err)
+		}
+	}
+
+	if len(expiredReaders) > 0 || len(expiredOriginals) > 0 {
+		log.Printf("Expired %d reader(s) and %d info message(s)", len(expiredReaders), len(expiredOriginals))
+	}
+}
+
+// closeExpiredReader replaces the reader page with a closed notice and removes its controls
+func closeExpiredReader(s discord.Client, session *ReadSession) {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s — Reader closed", session.Code),
+		Description: fmt.Sprintf("This reader was closed after %v of inactivity. React with 📖 on a new `/doujin` message to read again.", SessionTTL),
+		Color:       0x808080,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Stopped at page %d/%d", session.Current+1, session.Total)},
+	}
+	components := []discordgo.MessageComponent{}
+
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:          session.MessageID,
+		Channel:     session.ChannelID,
+		Embeds:      &[]*discordgo.MessageEmbed{embed},
+		Components:  &components,
+		Attachments: &[]*discordgo.MessageAttachment{},
+	})
+	if err != nil {
+		log.Printf("Failed to close expired reader %s: %v", session.ID, err)
+	}
+}
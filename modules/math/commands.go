@@ -1,6 +1,12 @@
 package math
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
 
 var CalculateCommand = []*discordgo.ApplicationCommand{
 	{
@@ -16,3 +22,10 @@ var CalculateCommand = []*discordgo.ApplicationCommand{
 		},
 	},
 }
+
+// CalculateLimits bounds how often /collatzconjecture runs, long inputs keep a CPU busy
+var CalculateLimits = discord.Limits{
+	PerUser:    discord.Bucket{Burst: 2, Every: 30 * time.Second},
+	PerGuild:   discord.Bucket{Burst: 5, Every: 30 * time.Second},
+	Concurrent: 2,
+}
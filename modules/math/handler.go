@@ -1,61 +1,62 @@
 package math
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	stdmath "math"
 	"runtime"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+
+	"test/modules/artifact"
+	"test/modules/discord"
 )
 
-func RegisterCollatzConjectureHandler(s *discordgo.Session) {
-	s.AddHandler(handleCollatzConjectureCommand)
+func RegisterCollatzConjectureHandler(r *discord.Router) {
+	r.Command(CalculateCommand[0], handleCollatzConjectureCommand, discord.RateLimit(CalculateLimits))
 }
 
-func collatzConjecture(n int) []int {
+// maxSteps bounds the length of a sequence; no number up to MaxValue needs
+// more than about 1400 steps
+const maxSteps = 10000
+
+var (
+	errOverflow = errors.New("sequence exceeds the integer range")
+	errTooLong  = fmt.Errorf("sequence is longer than %d steps", maxSteps)
+)
+
+func collatzConjecture(n int) ([]int, error) {
 	sequence := []int{n}
 	for n != 1 {
+		if len(sequence) > maxSteps {
+			return sequence, errTooLong
+		}
 		if n%2 == 0 {
 			n /= 2
 		} else {
+			if n > (stdmath.MaxInt-1)/3 {
+				return sequence, errOverflow
+			}
 			n = 3*n + 1
 		}
 		sequence = append(sequence, n)
 	}
-	return sequence
+	return sequence, nil
 }
 
-func processInput(inputStr string) ([]int, error) {
-	var numbers []int
-	parts := strings.Split(inputStr, ",")
-
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if strings.Contains(part, "-") {
-			rangeParts := strings.Split(part, "-")
-			if len(rangeParts) != 2 {
-				return nil, fmt.Errorf("invalid range: %s", part)
-			}
-			start, err1 := strconv.Atoi(strings.TrimSpace(rangeParts[0]))
-			end, err2 := strconv.Atoi(strings.TrimSpace(rangeParts[1]))
-			if err1 != nil || err2 != nil {
-				return nil, fmt.Errorf("invalid number in range: %s", part)
-			}
-			for i := start; i <= end; i++ {
-				numbers = append(numbers, i)
-			}
-		} else {
-			num, err := strconv.Atoi(part)
-			if err != nil {
-				return nil, fmt.Errorf("invalid number: %s", part)
-			}
-			numbers = append(numbers, num)
-		}
+// describeSequence formats the sequence of num for the output
+func describeSequence(num int) string {
+	result, err := collatzConjecture(num)
+	if err != nil {
+		return fmt.Sprintf("Collatz sequence for %d: stopped after %d steps, %v", num, len(result)-1, err)
 	}
-	return numbers, nil
+	return fmt.Sprintf("Collatz sequence for %d: %v", num, result)
+}
+
+func processInput(inputStr string) ([]int, error) {
+	return parseInput(inputStr, MaxInputCount)
 }
 
 // ProcessCollatzConjecture Now takes input string as a parameter instead of reading from stdin
@@ -69,8 +70,7 @@ func ProcessCollatzConjecture(inputStr string) {
 	startTime := time.Now()
 
 	for _, num := range inputNumbers {
-		result := collatzConjecture(num)
-		fmt.Printf("Collatz sequence for %d: %v\n", num, result)
+		fmt.Println(describeSequence(num))
 	}
 
 	elapsed := time.Since(startTime)
@@ -82,12 +82,23 @@ func ProcessCollatzConjecture(inputStr string) {
 	fmt.Printf("Performance: Time taken - %.6f seconds\n", elapsed.Seconds())
 }
 
-func handleCollatzConjectureCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func handleCollatzConjectureCommand(s discord.Client, i *discordgo.InteractionCreate) {
 	if !isCollatzConjectureCommand(i) {
 		return
 	}
 
-	inputStr := i.ApplicationCommandData().Options[0].StringValue()
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error: please provide the numbers to calculate",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+	inputStr := options[0].StringValue()
 
 	inputNumbers, err := processInput(strings.TrimSpace(inputStr))
 	if err != nil {
@@ -113,8 +124,7 @@ func handleCollatzConjectureCommand(s *discordgo.Session, i *discordgo.Interacti
 	var responseStrings []string
 
 	for _, num := range inputNumbers {
-		result := collatzConjecture(num)
-		responseStrings = append(responseStrings, fmt.Sprintf("Collatz sequence for %d: %v", num, result))
+		responseStrings = append(responseStrings, describeSequence(num))
 	}
 
 	elapsed := time.Since(startTime)
@@ -124,33 +134,28 @@ func handleCollatzConjectureCommand(s *discordgo.Session, i *discordgo.Interacti
 		runtime.NumCPU(), runtime.GOMAXPROCS(0), elapsed.Seconds())
 
 	fullResponse := strings.Join(responseStrings, "\n")
-	// save full response to local filesystem ./calc/collatz_conjecture_output_TIMESTAMP.txt
-	saveToFile := func(content string) {
-		filename := fmt.Sprintf("./calc/collatz_conjecture_output_%s.txt", time.Now().Format("20060102150405"))
-		err := os.WriteFile(filename, []byte(content+summary), 0644)
-		if err != nil {
-			fmt.Println("failed to save output to file:", err)
-		} else {
-			fmt.Println("output saved to file:", filename)
-		}
-	}
-	saveToFile(fullResponse)
+	filename := fmt.Sprintf("collatz_conjecture_output_%s.txt", time.Now().Format("20060102150405"))
 
 	first2000Response := fullResponse
 	if len(fullResponse) > 1000 {
 		first2000Response = fullResponse[:1000] + "\n\n[Output truncated. See full output below.]" + summary
+
+		// keep the full response in the artifact store so it can be downloaded again
+		stored, err := artifact.Keep(filename, "text/plain", strings.NewReader(fullResponse+summary))
+		if err != nil {
+			fmt.Println("failed to store output:", err)
+		} else {
+			first2000Response += "\n" + artifact.Reference(stored)
+		}
 	}
 	// cancel the previous deferred response and send the full response
 	var files []*discordgo.File
-	// Discord upload limit is 8 MB for normal bots
-	const discordUploadLimit = 8 * 1024 * 1024 // 8 MB
-
 	// Only attach a file if the response is reasonably sized
-	if len(fullResponse) < discordUploadLimit {
+	if len(fullResponse) < artifact.UploadLimit {
 		if len(fullResponse) > 1000 {
 			files = []*discordgo.File{
 				{
-					Name:        "collatz_conjecture_output_" + time.Now().Format("20060102150405") + ".txt",
+					Name:        filename,
 					ContentType: "text/plain",
 					Reader:      strings.NewReader(fullResponse + summary),
 				},
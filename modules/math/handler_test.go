@@ -0,0 +1,147 @@
+package math
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+func TestCollatzConjecture(t *testing.T) {
+	got, err := collatzConjecture(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{6, 3, 10, 5, 16, 8, 4, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("collatzConjecture(6) = %v, want %v", got, want)
+	}
+
+	got, err = collatzConjecture(27)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 112 {
+		t.Errorf("collatzConjecture(27) took %d steps, want 111", len(got)-1)
+	}
+}
+
+func TestCollatzConjectureOverflow(t *testing.T) {
+	// 3n+1 of these exceeds the int range, they used to wrap around and cycle
+	for _, n := range []int{4611686018427387901, 4611686018427387903, 1<<62 - 1} {
+		seq, err := collatzConjecture(n)
+		if !errors.Is(err, errOverflow) {
+			t.Errorf("collatzConjecture(%d) error = %v, want %v", n, err, errOverflow)
+		}
+		for _, v := range seq {
+			if v < 1 {
+				t.Errorf("collatzConjecture(%d) produced %d", n, v)
+				break
+			}
+		}
+	}
+}
+
+func TestCollatzConjectureMaxValue(t *testing.T) {
+	// 2^40-1 climbs past the int range, everything else near the cap finishes
+	for n := MaxValue - 1000; n <= MaxValue; n++ {
+		_, err := collatzConjecture(n)
+		if n == MaxValue-1 {
+			if !errors.Is(err, errOverflow) {
+				t.Errorf("collatzConjecture(%d) error = %v, want %v", n, err, errOverflow)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("collatzConjecture(%d): %v", n, err)
+		}
+	}
+}
+
+func TestDescribeSequenceReportsOverflow(t *testing.T) {
+	got := describeSequence(4611686018427387901)
+	if !strings.Contains(got, "stopped after") || !strings.Contains(got, errOverflow.Error()) {
+		t.Errorf("describeSequence = %q", got)
+	}
+}
+
+func collatzCommand(input string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "i1",
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "c1",
+		User:      &discordgo.User{ID: "u1"},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "collatzconjecture",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "int", Type: discordgo.ApplicationCommandOptionString, Value: input},
+			},
+		},
+	}}
+}
+
+func TestHandleCollatzConjectureCommand(t *testing.T) {
+	r := discord.NewRecorder()
+	handleCollatzConjectureCommand(r, collatzCommand("6, 7"))
+
+	responds := r.Calls("InteractionRespond")
+	if len(responds) != 1 || responds[0].Args[1].(*discordgo.InteractionResponse).Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Fatalf("interaction responses = %+v, want one deferred response", responds)
+	}
+	edits := r.Calls("InteractionResponseEdit")
+	if len(edits) != 1 {
+		t.Fatalf("got %d response edits, want 1", len(edits))
+	}
+	edit := edits[0].Args[1].(*discordgo.WebhookEdit)
+	if !strings.HasPrefix(*edit.Content, "Collatz sequence for 6: [6 3 10 5 16 8 4 2 1]\nCollatz sequence for 7: [7 22") {
+		t.Errorf("response = %q", *edit.Content)
+	}
+	if len(edit.Files) != 0 {
+		t.Errorf("short output attached %d files", len(edit.Files))
+	}
+}
+
+func TestHandleCollatzConjectureCommandAttachesLongOutput(t *testing.T) {
+	r := discord.NewRecorder()
+	handleCollatzConjectureCommand(r, collatzCommand("1-100"))
+
+	edits := r.Calls("InteractionResponseEdit")
+	if len(edits) != 1 {
+		t.Fatalf("got %d response edits, want 1", len(edits))
+	}
+	edit := edits[0].Args[1].(*discordgo.WebhookEdit)
+	if !strings.Contains(*edit.Content, "[Output truncated. See full output below.]") {
+		t.Errorf("long output was not truncated: %q", *edit.Content)
+	}
+	if len(edit.Files) != 1 {
+		t.Fatalf("got %d files, want the full output", len(edit.Files))
+	}
+	data, err := io.ReadAll(edit.Files[0].Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Collatz sequence for 100: [100 50 25") {
+		t.Error("attachment does not contain the whole output")
+	}
+}
+
+func TestHandleCollatzConjectureCommandRejectsBadInput(t *testing.T) {
+	r := discord.NewRecorder()
+	handleCollatzConjectureCommand(r, collatzCommand("3, x"))
+
+	responds := r.Calls("InteractionRespond")
+	if len(responds) != 1 {
+		t.Fatalf("got %d interaction responses, want 1", len(responds))
+	}
+	resp := responds[0].Args[1].(*discordgo.InteractionResponse)
+	if resp.Type != discordgo.InteractionResponseChannelMessageWithSource || !strings.HasPrefix(resp.Data.Content, "Error: ") {
+		t.Errorf("response = %+v, want an error message", resp.Data)
+	}
+	if edits := r.Calls("InteractionResponseEdit"); len(edits) > 0 {
+		t.Errorf("bad input was calculated: %+v", edits)
+	}
+}
@@ -0,0 +1,330 @@
+package math
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxInputCount is the maximum amount of numbers a single input may expand to
+var MaxInputCount = 10000
+
+// MaxValue is the largest number accepted. Sequences can climb far above
+// their starting value, so it stays well below the int range; collatzConjecture
+// still detects the rare sequence that would overflow
+const MaxValue = 1 << 40
+
+// Input grammar:
+//
+//	input := item ("," item)*
+//	item  := "!" number                 exclude a number
+//	       | number                     a single number
+//	       | number ".." number [":" number]   inclusive range with optional step
+//	       | number "-" number          inclusive range (legacy syntax)
+//	number := ["+" | "-"] digits ["." digits] [("e" | "E") ["+"] digits]
+//
+// Scientific notation is accepted as long as the value is a whole number,
+// e.g. 1e6 or 2.5e3.
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenComma
+	tokenRange // ..
+	tokenColon
+	tokenBang
+	tokenMinus
+	tokenPlus
+	tokenEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // 1-based position in the input
+}
+
+// ParseError points at the token that could not be parsed
+type ParseError struct {
+	Pos   int
+	Token string
+	Msg   string
+}
+
+func (e *ParseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s at end of input", e.Msg)
+	}
+	return fmt.Sprintf("%s at position %d (%q)", e.Msg, e.Pos, e.Token)
+}
+
+// tokenize splits the input into tokens, ignoring whitespace
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i + 1})
+			i++
+		case c == ':':
+			tokens = append(tokens, token{tokenColon, ":", i + 1})
+			i++
+		case c == '!':
+			tokens = append(tokens, token{tokenBang, "!", i + 1})
+			i++
+		case c == '-':
+			tokens = append(tokens, token{tokenMinus, "-", i + 1})
+			i++
+		case c == '+':
+			tokens = append(tokens, token{tokenPlus, "+", i + 1})
+			i++
+		case c == '.' && strings.HasPrefix(input[i:], ".."):
+			tokens = append(tokens, token{tokenRange, "..", i + 1})
+			i += 2
+		case isDigit(c):
+			start := i
+			i = scanNumber(input, i)
+			tokens = append(tokens, token{tokenNumber, input[start:i], start + 1})
+		default:
+			end := i + 1
+			for end < len(input) && !strings.ContainsRune(" \t\n,:!", rune(input[end])) {
+				end++
+			}
+			return nil, &ParseError{Pos: i + 1, Token: input[i:end], Msg: "unexpected character"}
+		}
+	}
+	tokens = append(tokens, token{tokenEOF, "", len(input) + 1})
+	return tokens, nil
+}
+
+// scanNumber returns the end of the number literal starting at i
+func scanNumber(input string, i int) int {
+	for i < len(input) && isDigit(input[i]) {
+		i++
+	}
+	// a single dot starts a fraction, two dots are the range operator
+	if i+1 < len(input) && input[i] == '.' && isDigit(input[i+1]) {
+		i++
+		for i < len(input) && isDigit(input[i]) {
+			i++
+		}
+	}
+	if i < len(input) && (input[i] == 'e' || input[i] == 'E') {
+		j := i + 1
+		if j < len(input) && input[j] == '+' {
+			j++
+		}
+		if j < len(input) && isDigit(input[j]) {
+			i = j
+			for i < len(input) && isDigit(input[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseLiteral converts a number literal to an int, allowing scientific
+// notation as long as the result is a whole number
+func parseLiteral(t token) (int, error) {
+	mantissa, exponent, _ := strings.Cut(strings.ToLower(t.text), "e")
+	whole, frac, _ := strings.Cut(mantissa, ".")
+
+	exp := 0
+	if exponent != "" {
+		for _, c := range strings.TrimPrefix(exponent, "+") {
+			exp = exp*10 + int(c-'0')
+			if exp > 18 {
+				return 0, &ParseError{Pos: t.pos, Token: t.text, Msg: "number is too large"}
+			}
+		}
+	}
+
+	// shift the decimal point by the exponent
+	if len(frac) > exp {
+		if strings.Trim(frac[exp:], "0") != "" {
+			return 0, &ParseError{Pos: t.pos, Token: t.text, Msg: "number must be a whole number"}
+		}
+		frac = frac[:exp]
+	}
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+
+	value := 0
+	for _, c := range digits {
+		if value > (MaxValue-int(c-'0'))/10 {
+			return 0, &ParseError{Pos: t.pos, Token: t.text, Msg: "number is too large"}
+		}
+		value = value*10 + int(c-'0')
+	}
+	return value, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) unexpected(t token, expected string) error {
+	return &ParseError{Pos: t.pos, Token: t.text, Msg: "expected " + expected}
+}
+
+// number parses an optionally signed number literal
+func (p *parser) number() (int, token, error) {
+	t := p.next()
+	sign := 1
+	first := t
+	if t.kind == tokenMinus || t.kind == tokenPlus {
+		if t.kind == tokenMinus {
+			sign = -1
+		}
+		t = p.next()
+	}
+	if t.kind != tokenNumber {
+		return 0, first, p.unexpected(t, "a number")
+	}
+	value, err := parseLiteral(t)
+	if err != nil {
+		return 0, first, err
+	}
+	if first != t {
+		first.text += t.text
+	}
+	return sign * value, first, nil
+}
+
+// positive parses a number and checks that it is at least 1
+func (p *parser) positive() (int, token, error) {
+	value, t, err := p.number()
+	if err != nil {
+		return 0, t, err
+	}
+	if value < 1 {
+		return 0, t, &ParseError{Pos: t.pos, Token: t.text, Msg: "number must be a positive integer"}
+	}
+	return value, t, nil
+}
+
+// span is a parsed range, single numbers are ranges of length one
+type span struct {
+	start, end, step int
+}
+
+func (s span) count() int {
+	return (s.end-s.start)/s.step + 1
+}
+
+// item parses a single comma separated entry
+func (p *parser) item() (sp span, exclude bool, err error) {
+	if p.peek().kind == tokenBang {
+		p.next()
+		value, _, err := p.positive()
+		return span{value, value, 1}, true, err
+	}
+
+	start, startTok, err := p.positive()
+	if err != nil {
+		return span{}, false, err
+	}
+
+	op := p.peek()
+	if op.kind != tokenRange && op.kind != tokenMinus {
+		return span{start, start, 1}, false, nil
+	}
+	p.next()
+
+	end, endTok, err := p.positive()
+	if err != nil {
+		return span{}, false, err
+	}
+	if end < start {
+		return span{}, false, &ParseError{Pos: startTok.pos, Token: startTok.text + op.text + endTok.text,
+			Msg: fmt.Sprintf("range start %d is greater than end %d", start, end)}
+	}
+
+	step := 1
+	if op.kind == tokenRange && p.peek().kind == tokenColon {
+		p.next()
+		var stepTok token
+		step, stepTok, err = p.positive()
+		if err != nil {
+			return span{}, false, err
+		}
+		if step > end-start && end != start {
+			return span{}, false, &ParseError{Pos: stepTok.pos, Token: stepTok.text, Msg: "step is larger than the range"}
+		}
+	}
+
+	return span{start, end, step}, false, nil
+}
+
+// parseInput parses the input grammar and expands it to a list of numbers,
+// refusing to produce more than maxCount numbers
+func parseInput(input string, maxCount int) ([]int, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	var spans []span
+	excluded := make(map[int]bool)
+	total := 0
+
+	for {
+		itemStart := p.peek()
+		sp, exclude, err := p.item()
+		if err != nil {
+			return nil, err
+		}
+		if exclude {
+			excluded[sp.start] = true
+		} else {
+			total += sp.count()
+			if total > maxCount {
+				return nil, &ParseError{Pos: itemStart.pos, Token: itemStart.text,
+					Msg: fmt.Sprintf("input expands to more than %d numbers", maxCount)}
+			}
+			spans = append(spans, sp)
+		}
+
+		t := p.next()
+		if t.kind == tokenEOF {
+			break
+		}
+		if t.kind != tokenComma {
+			return nil, p.unexpected(t, "',' or end of input")
+		}
+	}
+
+	numbers := make([]int, 0, total)
+	for _, sp := range spans {
+		for n := sp.start; n <= sp.end; n += sp.step {
+			if !excluded[n] {
+				numbers = append(numbers, n)
+			}
+		}
+	}
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("input does not contain any numbers")
+	}
+	return numbers, nil
+}
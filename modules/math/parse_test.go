@@ -0,0 +1,89 @@
+package math
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []int
+	}{
+		{"7", []int{7}},
+		{"1, 2,3", []int{1, 2, 3}},
+		{"1..5", []int{1, 2, 3, 4, 5}},
+		{"1..10:3", []int{1, 4, 7, 10}},
+		{"3-5", []int{3, 4, 5}},
+		{"1..5, !3", []int{1, 2, 4, 5}},
+		{"+4", []int{4}},
+		{"1e3", []int{1000}},
+		{"2.5e1", []int{25}},
+		{"1E+2", []int{100}},
+		{"5..5:3", []int{5}},
+		{strconv.Itoa(MaxValue), []int{MaxValue}},
+	}
+	for _, tt := range tests {
+		got, err := parseInput(tt.input, MaxInputCount)
+		if err != nil {
+			t.Errorf("parseInput(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseInput(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseInputErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  ParseError
+	}{
+		{"", ParseError{Pos: 1, Token: "", Msg: "expected a number"}},
+		{"1,", ParseError{Pos: 3, Token: "", Msg: "expected a number"}},
+		{"1,,2", ParseError{Pos: 3, Token: ",", Msg: "expected a number"}},
+		{"1 2", ParseError{Pos: 3, Token: "2", Msg: "expected ',' or end of input"}},
+		{"0", ParseError{Pos: 1, Token: "0", Msg: "number must be a positive integer"}},
+		{"1, -4", ParseError{Pos: 4, Token: "-4", Msg: "number must be a positive integer"}},
+		{"1, 2x", ParseError{Pos: 5, Token: "x", Msg: "unexpected character"}},
+		{"abc, 1", ParseError{Pos: 1, Token: "abc", Msg: "unexpected character"}},
+		{"1.5", ParseError{Pos: 1, Token: "1.5", Msg: "number must be a whole number"}},
+		{"10..5", ParseError{Pos: 1, Token: "10..5", Msg: "range start 10 is greater than end 5"}},
+		{"1..5:9", ParseError{Pos: 6, Token: "9", Msg: "step is larger than the range"}},
+		{"1..5:0", ParseError{Pos: 6, Token: "0", Msg: "number must be a positive integer"}},
+		{"1, 1e19", ParseError{Pos: 4, Token: "1e19", Msg: "number is too large"}},
+		{"1, " + strconv.Itoa(MaxValue+1), ParseError{Pos: 4, Token: strconv.Itoa(MaxValue + 1), Msg: "number is too large"}},
+		{"1, 2..20000", ParseError{Pos: 4, Token: "2", Msg: "input expands to more than 10000 numbers"}},
+	}
+	for _, tt := range tests {
+		_, err := parseInput(tt.input, MaxInputCount)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("parseInput(%q) error = %v, want %v", tt.input, err, &tt.want)
+			continue
+		}
+		if *perr != tt.want {
+			t.Errorf("parseInput(%q) error = %+v, want %+v", tt.input, *perr, tt.want)
+		}
+	}
+}
+
+func TestParseInputAllExcluded(t *testing.T) {
+	if _, err := parseInput("3, !3", MaxInputCount); err == nil {
+		t.Fatal("expected an error for an input without numbers")
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	err := &ParseError{Pos: 4, Token: "x", Msg: "unexpected character"}
+	if got, want := err.Error(), `unexpected character at position 4 ("x")`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	err = &ParseError{Pos: 3, Msg: "expected a number"}
+	if got, want := err.Error(), "expected a number at end of input"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,219 @@
+package mcproto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxStringLen is the protocol limit of a string in UTF-16 code units. The
+// encoded form may use up to three bytes per unit.
+const MaxStringLen = 32767
+
+var (
+	ErrStringTooLong = errors.New("mcproto: string is too long")
+	ErrInvalidString = errors.New("mcproto: string is not valid UTF-8")
+	ErrShortBuffer   = errors.New("mcproto: not enough data")
+)
+
+// UUID is a 128 bit identifier, sent as two big endian longs
+type UUID [16]byte
+
+// String formats the UUID with dashes
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// ParseUUID parses a UUID with or without dashes
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	raw := strings.ReplaceAll(s, "-", "")
+	if len(raw) != 32 {
+		return u, fmt.Errorf("mcproto: invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(raw)); err != nil {
+		return u, fmt.Errorf("mcproto: invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// Encoder builds packet payloads. The first field that can not be encoded
+// is remembered and returned by Bytes, so calls can be chained.
+type Encoder struct {
+	buf []byte
+	err error
+}
+
+// Bytes returns the encoded data, or the error of the first field that
+// could not be encoded
+func (e *Encoder) Bytes() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.buf, nil
+}
+
+func (e *Encoder) VarInt(v int32) *Encoder {
+	e.buf = AppendVarInt(e.buf, v)
+	return e
+}
+
+func (e *Encoder) VarLong(v int64) *Encoder {
+	e.buf = AppendVarLong(e.buf, v)
+	return e
+}
+
+// String writes a VarInt length prefixed UTF-8 string of at most
+// MaxStringLen UTF-16 code units
+func (e *Encoder) String(s string) *Encoder {
+	if utf16Len([]byte(s)) > MaxStringLen {
+		if e.err == nil {
+			e.err = ErrStringTooLong
+		}
+		return e
+	}
+	e.buf = AppendVarInt(e.buf, int32(len(s)))
+	e.buf = append(e.buf, s...)
+	return e
+}
+
+func (e *Encoder) UnsignedShort(v uint16) *Encoder {
+	e.buf = binary.BigEndian.AppendUint16(e.buf, v)
+	return e
+}
+
+func (e *Encoder) Long(v int64) *Encoder {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v))
+	return e
+}
+
+func (e *Encoder) UUID(u UUID) *Encoder {
+	e.buf = append(e.buf, u[:]...)
+	return e
+}
+
+func (e *Encoder) Raw(data []byte) *Encoder {
+	e.buf = append(e.buf, data...)
+	return e
+}
+
+// Decoder reads fields from a packet payload. Every read checks that enough
+// data is left, so malformed input results in an error instead of a panic.
+type Decoder struct {
+	r *bytes.Reader
+}
+
+// NewDecoder returns a decoder reading data
+func NewDecoder(data []byte) *Decoder {
+	return &Decoder{r: bytes.NewReader(data)}
+}
+
+// Remaining returns the number of unread bytes
+func (d *Decoder) Remaining() int {
+	return d.r.Len()
+}
+
+func (d *Decoder) VarInt() (int32, error) {
+	v, err := ReadVarInt(d.r)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = ErrShortBuffer
+	}
+	return v, err
+}
+
+func (d *Decoder) VarLong() (int64, error) {
+	v, err := ReadVarLong(d.r)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = ErrShortBuffer
+	}
+	return v, err
+}
+
+// String reads a length prefixed string of at most maxLen UTF-16 code units;
+// a maxLen of zero uses MaxStringLen
+func (d *Decoder) String(maxLen int) (string, error) {
+	if maxLen <= 0 || maxLen > MaxStringLen {
+		maxLen = MaxStringLen
+	}
+
+	length, err := d.VarInt()
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || int(length) > maxLen*3 {
+		return "", ErrStringTooLong
+	}
+
+	data, err := d.read(int(length))
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(data) {
+		return "", ErrInvalidString
+	}
+	if utf16Len(data) > maxLen {
+		return "", ErrStringTooLong
+	}
+	return string(data), nil
+}
+
+func (d *Decoder) UnsignedShort() (uint16, error) {
+	data, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(data), nil
+}
+
+func (d *Decoder) Long() (int64, error) {
+	data, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
+
+func (d *Decoder) UUID() (UUID, error) {
+	var u UUID
+	data, err := d.read(16)
+	if err != nil {
+		return u, err
+	}
+	copy(u[:], data)
+	return u, nil
+}
+
+// Rest returns all unread bytes
+func (d *Decoder) Rest() []byte {
+	data, _ := d.read(d.r.Len())
+	return data
+}
+
+func (d *Decoder) read(n int) ([]byte, error) {
+	if n > d.r.Len() {
+		return nil, ErrShortBuffer
+	}
+	data := make([]byte, n)
+	io.ReadFull(d.r, data)
+	return data, nil
+}
+
+// utf16Len counts the UTF-16 code units of valid UTF-8 data
+func utf16Len(data []byte) int {
+	n := 0
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		n++
+		if r >= 0x10000 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,260 @@
+package mcproto
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestVarIntEncoding(t *testing.T) {
+	tests := []struct {
+		value int32
+		data  []byte
+	}{
+		{0, []byte{0x00}},
+		{1, []byte{0x01}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{255, []byte{0xff, 0x01}},
+		{25565, []byte{0xdd, 0xc7, 0x01}},
+		{2097151, []byte{0xff, 0xff, 0x7f}},
+		{math.MaxInt32, []byte{0xff, 0xff, 0xff, 0xff, 0x07}},
+		{-1, []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+		{math.MinInt32, []byte{0x80, 0x80, 0x80, 0x80, 0x08}},
+	}
+	for _, tt := range tests {
+		if got := AppendVarInt(nil, tt.value); !bytes.Equal(got, tt.data) {
+			t.Errorf("AppendVarInt(%d) = % x, want % x", tt.value, got, tt.data)
+		}
+		if got := VarIntLen(tt.value); got != len(tt.data) {
+			t.Errorf("VarIntLen(%d) = %d, want %d", tt.value, got, len(tt.data))
+		}
+		got, err := ReadVarInt(bytes.NewReader(tt.data))
+		if err != nil || got != tt.value {
+			t.Errorf("ReadVarInt(% x) = %d, %v, want %d", tt.data, got, err, tt.value)
+		}
+	}
+}
+
+func TestReadVarIntErrors(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0x10}, ErrVarIntTooBig},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, ErrVarIntTooBig},
+		{[]byte{0x80}, io.ErrUnexpectedEOF},
+		{nil, io.EOF},
+	}
+	for _, tt := range tests {
+		if _, err := ReadVarInt(bytes.NewReader(tt.data)); !errors.Is(err, tt.err) {
+			t.Errorf("ReadVarInt(% x) error = %v, want %v", tt.data, err, tt.err)
+		}
+	}
+}
+
+func FuzzVarInt(f *testing.F) {
+	for _, v := range []int32{0, 1, 127, 128, 25565, math.MaxInt32, -1, math.MinInt32} {
+		f.Add(AppendVarInt(nil, v))
+	}
+	f.Add([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x01})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0x1f})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := bytes.NewReader(data)
+		v, err := ReadVarInt(r)
+		if err != nil {
+			return
+		}
+		if read := len(data) - r.Len(); read > MaxVarIntLen {
+			t.Fatalf("read %d bytes for one VarInt", read)
+		}
+
+		encoded := AppendVarInt(nil, v)
+		if len(encoded) != VarIntLen(v) {
+			t.Fatalf("VarIntLen(%d) = %d, encoded %d bytes", v, VarIntLen(v), len(encoded))
+		}
+		again, err := ReadVarInt(bytes.NewReader(encoded))
+		if err != nil || again != v {
+			t.Fatalf("round trip of %d gave %d, %v", v, again, err)
+		}
+	})
+}
+
+func FuzzVarLong(f *testing.F) {
+	for _, v := range []int64{0, 1, 127, 128, math.MaxInt64, -1, math.MinInt64} {
+		f.Add(AppendVarLong(nil, v))
+	}
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := bytes.NewReader(data)
+		v, err := ReadVarLong(r)
+		if err != nil {
+			return
+		}
+		if read := len(data) - r.Len(); read > MaxVarLongLen {
+			t.Fatalf("read %d bytes for one VarLong", read)
+		}
+
+		encoded, _ := (&Encoder{}).VarLong(v).Bytes()
+		if len(encoded) > MaxVarLongLen {
+			t.Fatalf("encoded %d in %d bytes", v, len(encoded))
+		}
+		again, err := ReadVarLong(bytes.NewReader(encoded))
+		if err != nil || again != v {
+			t.Fatalf("round trip of %d gave %d, %v", v, again, err)
+		}
+	})
+}
+
+func FuzzDecoderString(f *testing.F) {
+	f.Add("", 0)
+	f.Add("localhost", 255)
+	f.Add("§aA Minecraft Server", 0)
+	f.Add("😀😀", 3)
+	f.Add("\xff\xfe", 0)
+
+	f.Fuzz(func(t *testing.T, s string, maxLen int) {
+		data, err := (&Encoder{}).String(s).Long(42).Bytes()
+		if err != nil {
+			if utf16Len([]byte(s)) <= MaxStringLen {
+				t.Fatalf("encoding %q: %v", s, err)
+			}
+			return
+		}
+		d := NewDecoder(data)
+		got, err := d.String(maxLen)
+
+		limit := maxLen
+		if limit <= 0 || limit > MaxStringLen {
+			limit = MaxStringLen
+		}
+		switch {
+		case !utf8.ValidString(s):
+			if err == nil {
+				t.Fatalf("accepted invalid UTF-8 %q", s)
+			}
+			return
+		case utf16Len([]byte(s)) > limit:
+			if !errors.Is(err, ErrStringTooLong) {
+				t.Fatalf("string of %d units with limit %d: got %v", utf16Len([]byte(s)), limit, err)
+			}
+			return
+		case err != nil:
+			t.Fatalf("String(%q): %v", s, err)
+		case got != s:
+			t.Fatalf("round trip of %q gave %q", s, got)
+		}
+
+		// the decoder stops right after the string
+		if v, err := d.Long(); err != nil || v != 42 {
+			t.Fatalf("field after the string: %d, %v", v, err)
+		}
+	})
+}
+
+func FuzzDecoderStringBytes(f *testing.F) {
+	f.Add([]byte{0x03, 'a', 'b', 'c'})
+	f.Add([]byte{0x05, 'a'})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0x07})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0x0f})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		s, err := NewDecoder(data).String(0)
+		if err != nil {
+			return
+		}
+		if !utf8.ValidString(s) || utf16Len([]byte(s)) > MaxStringLen {
+			t.Fatalf("decoded an invalid string of %d bytes", len(s))
+		}
+		encoded, err := (&Encoder{}).String(s).Bytes()
+		if err != nil {
+			t.Fatalf("encoding a decoded string: %v", err)
+		}
+		again, err := NewDecoder(encoded).String(0)
+		if err != nil || again != s {
+			t.Fatalf("round trip failed: %v", err)
+		}
+	})
+}
+
+func TestEncoderStringTooLong(t *testing.T) {
+	tests := []struct {
+		s   string
+		err error
+	}{
+		{strings.Repeat("a", MaxStringLen), nil},
+		{strings.Repeat("a", MaxStringLen+1), ErrStringTooLong},
+		// every emoji takes two UTF-16 code units
+		{strings.Repeat("😀", MaxStringLen/2), nil},
+		{strings.Repeat("😀", MaxStringLen/2+1), ErrStringTooLong},
+	}
+	for _, tt := range tests {
+		data, err := (&Encoder{}).String(tt.s).Long(42).Bytes()
+		if !errors.Is(err, tt.err) {
+			t.Errorf("String of %d bytes: error = %v, want %v", len(tt.s), err, tt.err)
+			continue
+		}
+		if err != nil {
+			if data != nil {
+				t.Errorf("String of %d bytes returned data with the error", len(tt.s))
+			}
+			continue
+		}
+		if got, err := NewDecoder(data).String(0); err != nil || got != tt.s {
+			t.Errorf("String of %d bytes does not decode: %v", len(tt.s), err)
+		}
+	}
+}
+
+func FuzzReadPacket(f *testing.F) {
+	var buf bytes.Buffer
+	status, _ := (&Encoder{}).String(`{"version":{}}`).Bytes()
+	WritePacket(&buf, Packet{ID: 0x00, Data: status})
+	f.Add(buf.Bytes())
+	f.Add([]byte{0x01, 0x00})
+	f.Add([]byte{0x00})
+	f.Add([]byte{0x05, 0x01, 0x02})
+	f.Add([]byte{0xff, 0xff, 0xff, 0x0f})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p, err := ReadPacket(bufio.NewReader(bytes.NewReader(data)), 4096)
+		if err != nil {
+			return
+		}
+
+		var out bytes.Buffer
+		if err := WritePacket(&out, p); err != nil {
+			t.Fatalf("WritePacket: %v", err)
+		}
+		again, err := ReadPacket(bufio.NewReader(&out), 4096)
+		if err != nil {
+			t.Fatalf("reading a written packet: %v", err)
+		}
+		if again.ID != p.ID || !bytes.Equal(again.Data, p.Data) {
+			t.Fatalf("round trip of packet 0x%02x changed it", p.ID)
+		}
+	})
+}
+
+func TestReadPacketLimits(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePacket(&buf, Packet{ID: 0x00, Data: []byte(strings.Repeat("x", 100))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPacket(bufio.NewReader(bytes.NewReader(buf.Bytes())), 50); !errors.Is(err, ErrPacketTooLarge) {
+		t.Errorf("packet above the limit: got %v, want %v", err, ErrPacketTooLarge)
+	}
+	if _, err := ReadPacket(bufio.NewReader(bytes.NewReader(buf.Bytes()[:20])), 0); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated packet: got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if err := WritePacket(&buf, Packet{Data: make([]byte, MaxPacketLen)}); !errors.Is(err, ErrPacketTooLarge) {
+		t.Errorf("writing an oversized packet: got %v, want %v", err, ErrPacketTooLarge)
+	}
+}
@@ -0,0 +1,73 @@
+package mcproto
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxPacketLen is the largest packet length the protocol allows
+const MaxPacketLen = 1<<21 - 1
+
+var ErrPacketTooLarge = errors.New("mcproto: packet is too large")
+
+// Packet is an uncompressed packet
+type Packet struct {
+	ID   int32
+	Data []byte
+}
+
+// Reader is what ReadPacket needs to read from, e.g. a *bufio.Reader
+type Reader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// WritePacket writes a length prefixed packet
+func WritePacket(w io.Writer, p Packet) error {
+	length := VarIntLen(p.ID) + len(p.Data)
+	if length > MaxPacketLen {
+		return ErrPacketTooLarge
+	}
+
+	buf := make([]byte, 0, MaxVarIntLen+length)
+	buf = AppendVarInt(buf, int32(length))
+	buf = AppendVarInt(buf, p.ID)
+	buf = append(buf, p.Data...)
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadPacket reads a length prefixed packet of at most maxLen bytes;
+// a maxLen of zero uses MaxPacketLen
+func ReadPacket(r Reader, maxLen int) (Packet, error) {
+	if maxLen <= 0 || maxLen > MaxPacketLen {
+		maxLen = MaxPacketLen
+	}
+
+	length, err := ReadVarInt(r)
+	if err != nil {
+		return Packet{}, err
+	}
+	if length <= 0 {
+		return Packet{}, fmt.Errorf("mcproto: invalid packet length %d", length)
+	}
+	if int(length) > maxLen {
+		return Packet{}, ErrPacketTooLarge
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Packet{}, err
+	}
+
+	d := NewDecoder(data)
+	id, err := d.VarInt()
+	if err != nil {
+		return Packet{}, err
+	}
+	return Packet{ID: id, Data: d.Rest()}, nil
+}
@@ -0,0 +1,97 @@
+package mcproto
+
+import (
+	"errors"
+	"io"
+)
+
+const (
+	// MaxVarIntLen is the maximum encoded size of a VarInt
+	MaxVarIntLen = 5
+	// MaxVarLongLen is the maximum encoded size of a VarLong
+	MaxVarLongLen = 10
+)
+
+var (
+	ErrVarIntTooBig  = errors.New("mcproto: VarInt is longer than 5 bytes")
+	ErrVarLongTooBig = errors.New("mcproto: VarLong is longer than 10 bytes")
+)
+
+// AppendVarInt appends the VarInt encoding of v to b
+func AppendVarInt(b []byte, v int32) []byte {
+	u := uint32(v)
+	for u >= 0x80 {
+		b = append(b, byte(u)|0x80)
+		u >>= 7
+	}
+	return append(b, byte(u))
+}
+
+// AppendVarLong appends the VarLong encoding of v to b
+func AppendVarLong(b []byte, v int64) []byte {
+	u := uint64(v)
+	for u >= 0x80 {
+		b = append(b, byte(u)|0x80)
+		u >>= 7
+	}
+	return append(b, byte(u))
+}
+
+// VarIntLen returns the number of bytes needed to encode v
+func VarIntLen(v int32) int {
+	u := uint32(v)
+	n := 1
+	for u >= 0x80 {
+		u >>= 7
+		n++
+	}
+	return n
+}
+
+// ReadVarInt reads a VarInt, rejecting encodings longer than MaxVarIntLen
+// or with bits beyond 32 set
+func ReadVarInt(r io.ByteReader) (int32, error) {
+	var value uint32
+	for i := 0; i < MaxVarIntLen; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			if i > 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		// the fifth byte may only carry the top four bits
+		if i == MaxVarIntLen-1 && b&0xF0 != 0 {
+			return 0, ErrVarIntTooBig
+		}
+		value |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(value), nil
+		}
+	}
+	return 0, ErrVarIntTooBig
+}
+
+// ReadVarLong reads a VarLong, rejecting encodings longer than MaxVarLongLen
+// or with bits beyond 64 set
+func ReadVarLong(r io.ByteReader) (int64, error) {
+	var value uint64
+	for i := 0; i < MaxVarLongLen; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			if i > 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		// the tenth byte may only carry the top bit
+		if i == MaxVarLongLen-1 && b&0xFE != 0 {
+			return 0, ErrVarLongTooBig
+		}
+		value |= uint64(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int64(value), nil
+		}
+	}
+	return 0, ErrVarLongTooBig
+}
@@ -0,0 +1,117 @@
+package minecraft
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultBedrockPort = 19132
+
+const (
+	raknetUnconnectedPing = 0x01
+	raknetUnconnectedPong = 0x1C
+)
+
+// raknetMagic identifies offline RakNet messages
+var raknetMagic = []byte{
+	0x00, 0xFF, 0xFF, 0x00, 0xFE, 0xFE, 0xFE, 0xFE,
+	0xFD, 0xFD, 0xFD, 0xFD, 0x12, 0x34, 0x56, 0x78,
+}
+
+// queryBedrock sends a RakNet unconnected ping over UDP
+func queryBedrock(address string) (*Status, error) {
+	host, port, err := resolveEditionAddress(address, EditionBedrock)
+	if err != nil {
+		return nil, err
+	}
+	target := net.JoinHostPort(host, strconv.Itoa(int(port)))
+
+	conn, err := net.DialTimeout("udp", target, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	status, err := queryBedrockStatus(conn)
+	if err != nil {
+		return nil, err
+	}
+	status.Address = target
+	return status, nil
+}
+
+// queryBedrockStatus exchanges a single ping and pong over conn, which must
+// preserve datagram boundaries
+func queryBedrockStatus(conn io.ReadWriter) (*Status, error) {
+	var guid [8]byte
+	rand.Read(guid[:])
+
+	sent := time.Now()
+	ping := []byte{raknetUnconnectedPing}
+	ping = binary.BigEndian.AppendUint64(ping, uint64(sent.UnixMilli()))
+	ping = append(ping, raknetMagic...)
+	ping = append(ping, guid[:]...)
+	if _, err := conn.Write(ping); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("no answer: %w", err)
+	}
+	latency := time.Since(sent)
+
+	// id, time, server GUID, magic, string length, server ID string
+	pong := buf[:n]
+	const header = 1 + 8 + 8 + 16 + 2
+	if len(pong) < header || pong[0] != raknetUnconnectedPong {
+		return nil, errors.New("unexpected RakNet response")
+	}
+	if !bytes.Equal(pong[17:33], raknetMagic) {
+		return nil, errors.New("invalid RakNet magic")
+	}
+	length := int(binary.BigEndian.Uint16(pong[33:35]))
+	if len(pong) < header+length {
+		return nil, errors.New("truncated RakNet response")
+	}
+
+	status, err := parseBedrockStatus(string(pong[header : header+length]))
+	if err != nil {
+		return nil, err
+	}
+	status.Latency = latency
+	return status, nil
+}
+
+// parseBedrockStatus decodes the server ID string:
+// edition;motd;protocol;version;online;max;server id;sub motd;game mode;...
+func parseBedrockStatus(id string) (*Status, error) {
+	fields := strings.Split(id, ";")
+	if len(fields) < 6 {
+		return nil, errors.New("malformed Bedrock status")
+	}
+
+	status := &Status{Edition: EditionBedrock}
+	status.Description.Text = fields[1]
+	status.Version.Protocol, _ = strconv.Atoi(fields[2])
+	status.Version.Name = fields[3]
+	status.Players.Online, _ = strconv.Atoi(fields[4])
+	status.Players.Max, _ = strconv.Atoi(fields[5])
+	if len(fields) > 7 && fields[7] != "" {
+		status.Description.Extra = []Chat{{Text: "\n" + fields[7]}}
+	}
+	if len(fields) > 8 {
+		status.GameMode = fields[8]
+	}
+	return status, nil
+}
@@ -0,0 +1,110 @@
+package minecraft
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+var (
+	minWatchMinutes = 1.0
+	minWatchPlayers = 1.0
+	watchAddressOpt = &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "address",
+		Description: "The server address as host or host:port",
+		Required:    true,
+	}
+)
+
+var MinecraftCommand = []*discordgo.ApplicationCommand{statusCommand, watchCommand}
+
+// StatusLimits bounds how often /mcstatus opens connections to servers
+var StatusLimits = discord.Limits{
+	PerUser:    discord.Bucket{Burst: 3, Every: 15 * time.Second},
+	PerCommand: discord.Bucket{Burst: 10, Every: 5 * time.Second},
+	Concurrent: 5,
+}
+
+var statusCommand = &discordgo.ApplicationCommand{
+	Name:        "mcstatus",
+	Description: "Show the status of a Minecraft server",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "address",
+			Description: "The server address as host or host:port (e.g., mc.hypixel.net)",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "edition",
+			Description: "Which edition the server runs (default: try Java, then Bedrock)",
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "Auto", Value: string(EditionAuto)},
+				{Name: "Java", Value: string(EditionJava)},
+				{Name: "Bedrock", Value: string(EditionBedrock)},
+			},
+		},
+	},
+}
+
+// WatchAccess limits adding and removing watched servers to members who
+// manage the server, everyone can list them and see their stats
+var WatchAccess = map[string]discord.Access{
+	"add":    {Permissions: discordgo.PermissionManageGuild},
+	"remove": {Permissions: discordgo.PermissionManageGuild},
+}
+
+var watchCommand = &discordgo.ApplicationCommand{
+	Name:        "mcwatch",
+	Description: "Monitor Minecraft servers and get notified when they go up or down",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "add",
+			Description: "Start watching a server",
+			Options: []*discordgo.ApplicationCommandOption{
+				watchAddressOpt,
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "Where notifications are posted (defaults to this channel)",
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "interval",
+					Description: "Minutes between checks (default 5)",
+					MinValue:    &minWatchMinutes,
+					MaxValue:    1440,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "threshold",
+					Description: "Notify when the player count rises above or falls below this",
+					MinValue:    &minWatchPlayers,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove",
+			Description: "Stop watching a server",
+			Options:     []*discordgo.ApplicationCommandOption{watchAddressOpt},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "Show the watched servers",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "stats",
+			Description: "Show the uptime history of a watched server",
+			Options:     []*discordgo.ApplicationCommandOption{watchAddressOpt},
+		},
+	},
+}
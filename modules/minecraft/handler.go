@@ -0,0 +1,122 @@
+package minecraft
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+func RegisterMinecraftHandler(r *discord.Router) {
+	// connecting may take a few seconds
+	r.Command(statusCommand, handleStatusCommand, discord.RateLimit(StatusLimits), discord.Defer(false))
+	r.Command(watchCommand, handleWatchCommand)
+	r.RestrictSubcommands(watchCommand, WatchAccess)
+
+	loadWatches()
+	go runWatcher(r.Client())
+}
+
+// handleStatusCommand processes the /mcstatus command
+func handleStatusCommand(s discord.Client, i *discordgo.InteractionCreate) {
+	if !isStatusCommand(i) {
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		discord.RespondEphemeral(s, i, "❌ Please provide a server address")
+		return
+	}
+	var address string
+	edition := EditionAuto
+	for _, option := range options {
+		switch option.Name {
+		case "address":
+			address = option.StringValue()
+		case "edition":
+			edition = Edition(option.StringValue())
+		}
+	}
+
+	status, err := Query(address, edition)
+	if err != nil {
+		log.Printf("Status query for %s failed: %v", address, err)
+		content := fmt.Sprintf("🔴 **%s** is offline or unreachable: %v", address, err)
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return
+	}
+
+	embed, files := buildStatusEmbed(address, status)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{embed},
+		Files:  files,
+	}); err != nil {
+		log.Printf("Failed to send status of %s: %v", address, err)
+	}
+}
+
+// buildStatusEmbed creates the status embed, attaching the server icon if it has one
+func buildStatusEmbed(address string, status *Status) (*discordgo.MessageEmbed, []*discordgo.File) {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🟢 %s", address),
+		Description: "```\n" + strings.TrimSpace(status.Description.String()) + "\n```",
+		Color:       0x55FF55,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Version", Value: stripFormatting(status.Version.Name), Inline: true},
+			{Name: "Players", Value: fmt.Sprintf("%d/%d", status.Players.Online, status.Players.Max), Inline: true},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: status.Address},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	edition := "Java"
+	if status.Edition == EditionBedrock {
+		edition = "Bedrock"
+	} else if status.Legacy {
+		edition = "Java (legacy ping)"
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Edition", Value: edition, Inline: true})
+	if status.GameMode != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Game mode", Value: status.GameMode, Inline: true})
+	}
+
+	if status.Latency > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Ping", Value: fmt.Sprintf("%d ms", status.Latency.Milliseconds()), Inline: true,
+		})
+	}
+
+	if len(status.Players.Sample) > 0 {
+		names := make([]string, 0, len(status.Players.Sample))
+		for _, p := range status.Players.Sample {
+			names = append(names, stripFormatting(p.Name))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Online players", Value: strings.Join(names, ", "), Inline: false,
+		})
+	}
+
+	var files []*discordgo.File
+	if icon, ok := strings.CutPrefix(status.Favicon, "data:image/png;base64,"); ok {
+		if data, err := base64.StdEncoding.DecodeString(icon); err == nil {
+			files = append(files, &discordgo.File{Name: "favicon.png", ContentType: "image/png", Reader: bytes.NewReader(data)})
+			embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: "attachment://favicon.png"}
+		}
+	}
+
+	return embed, files
+}
+
+func isStatusCommand(i *discordgo.InteractionCreate) bool {
+	return i != nil &&
+		i.Interaction != nil &&
+		i.Type == discordgo.InteractionApplicationCommand &&
+		i.ApplicationCommandData().Name == "mcstatus"
+}
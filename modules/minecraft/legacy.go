@@ -0,0 +1,96 @@
+package minecraft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxLegacyLen bounds the kick message of a legacy ping response, in UTF-16 units
+const maxLegacyLen = 32767
+
+// queryLegacyStatus performs the ping used before 1.7. Servers from 1.4 to 1.6
+// answer with a §1 prefixed, NUL separated response and older ones with
+// "motd§online§max"; both arrive as a kick packet
+func queryLegacyStatus(conn io.ReadWriter, host string, port uint16) (*Status, error) {
+	// 0xFE 0x01 followed by the MC|PingHost plugin message 1.6 servers expect
+	hostUnits := utf16.Encode([]rune(host))
+	channel := utf16.Encode([]rune("MC|PingHost"))
+
+	request := []byte{0xFE, 0x01, 0xFA}
+	request = binary.BigEndian.AppendUint16(request, uint16(len(channel)))
+	request = appendUTF16(request, channel)
+	request = binary.BigEndian.AppendUint16(request, uint16(7+2*len(hostUnits)))
+	request = append(request, 78) // last protocol that used this ping
+	request = binary.BigEndian.AppendUint16(request, uint16(len(hostUnits)))
+	request = appendUTF16(request, hostUnits)
+	request = binary.BigEndian.AppendUint32(request, uint32(port))
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	id, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy status: %w", err)
+	}
+	if id != 0xFF {
+		return nil, fmt.Errorf("unexpected legacy packet 0x%02x", id)
+	}
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, fmt.Errorf("failed to read legacy status: %w", err)
+	}
+	if length > maxLegacyLen {
+		return nil, fmt.Errorf("legacy status too long (%d)", length)
+	}
+	units := make([]uint16, length)
+	if err := binary.Read(r, binary.BigEndian, units); err != nil {
+		return nil, fmt.Errorf("failed to read legacy status: %w", err)
+	}
+
+	return parseLegacyStatus(string(utf16.Decode(units)))
+}
+
+// parseLegacyStatus decodes the kick message of a legacy ping
+func parseLegacyStatus(response string) (*Status, error) {
+	status := &Status{Edition: EditionJava, Legacy: true}
+
+	if rest, ok := strings.CutPrefix(response, "§1\x00"); ok {
+		// protocol, version, motd, online, max
+		fields := strings.Split(rest, "\x00")
+		if len(fields) != 5 {
+			return nil, errors.New("malformed legacy status")
+		}
+		status.Version.Protocol, _ = strconv.Atoi(fields[0])
+		status.Version.Name = fields[1]
+		status.Description.Text = fields[2]
+		status.Players.Online, _ = strconv.Atoi(fields[3])
+		status.Players.Max, _ = strconv.Atoi(fields[4])
+		return status, nil
+	}
+
+	// beta 1.8 to 1.3: the motd may itself contain §, the counts are last
+	fields := strings.Split(response, "§")
+	if len(fields) < 3 {
+		return nil, errors.New("malformed legacy status")
+	}
+	n := len(fields)
+	status.Description.Text = strings.Join(fields[:n-2], "§")
+	status.Players.Online, _ = strconv.Atoi(fields[n-2])
+	status.Players.Max, _ = strconv.Atoi(fields[n-1])
+	status.Version.Name = "Beta 1.8 - 1.3"
+	return status, nil
+}
+
+func appendUTF16(b []byte, units []uint16) []byte {
+	for _, u := range units {
+		b = binary.BigEndian.AppendUint16(b, u)
+	}
+	return b
+}
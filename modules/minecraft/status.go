@@ -0,0 +1,264 @@
+package minecraft
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"test/modules/mcproto"
+)
+
+const (
+	defaultPort = 25565
+	dialTimeout = 5 * time.Second
+)
+
+// Edition selects which Minecraft edition a status query speaks
+type Edition string
+
+const (
+	EditionAuto    Edition = "auto"
+	EditionJava    Edition = "java"
+	EditionBedrock Edition = "bedrock"
+)
+
+// statusProtocols are tried in order during the handshake. Servers answer
+// status requests for any version and -1 asks them to report their own, but
+// some proxies reject unknown versions, so known releases follow as fallbacks
+var statusProtocols = []int32{-1, 767, 754, 47}
+
+// Status is the server list ping response
+type Status struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+		Sample []struct {
+			Name string `json:"name"`
+			ID   string `json:"id"`
+		} `json:"sample"`
+	} `json:"players"`
+	Description Chat   `json:"description"`
+	Favicon     string `json:"favicon"` // data:image/png;base64,...
+
+	Address  string        `json:"-"` // the address that was queried
+	Latency  time.Duration `json:"-"`
+	Edition  Edition       `json:"-"`
+	Legacy   bool          `json:"-"` // answered the pre-1.7 ping
+	GameMode string        `json:"-"` // only reported by Bedrock servers
+}
+
+// Chat is a text component, which servers send either as a plain string or as an object
+type Chat struct {
+	Text  string `json:"text"`
+	Extra []Chat `json:"extra"`
+}
+
+func (c *Chat) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		c.Text = text
+		return nil
+	}
+
+	type chat Chat // avoid recursing into this method
+	return json.Unmarshal(data, (*chat)(c))
+}
+
+// String returns the plain text of the component and its children without formatting codes
+func (c Chat) String() string {
+	var b strings.Builder
+	b.WriteString(c.Text)
+	for _, extra := range c.Extra {
+		b.WriteString(extra.String())
+	}
+	return stripFormatting(b.String())
+}
+
+// stripFormatting removes legacy § color and style codes
+func stripFormatting(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '§' {
+			i++
+			continue
+		}
+		b.WriteRune(runes[i])
+	}
+	return b.String()
+}
+
+// resolveAddress splits host[:port], looking up the SRV record when no port is given
+func resolveAddress(address string) (host string, port uint16, err error) {
+	return resolveEditionAddress(address, EditionJava)
+}
+
+// resolveEditionAddress resolves address using the default port of edition.
+// Bedrock servers have no SRV records
+func resolveEditionAddress(address string, edition Edition) (host string, port uint16, err error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", 0, errors.New("address is empty")
+	}
+
+	if h, p, err := net.SplitHostPort(address); err == nil {
+		n, err := strconv.ParseUint(p, 10, 16)
+		if err != nil || n == 0 {
+			return "", 0, fmt.Errorf("invalid port %q", p)
+		}
+		return h, uint16(n), nil
+	}
+
+	if edition == EditionBedrock {
+		return address, defaultBedrockPort, nil
+	}
+
+	if _, records, err := net.LookupSRV("minecraft", "tcp", address); err == nil && len(records) > 0 {
+		return strings.TrimSuffix(records[0].Target, "."), records[0].Port, nil
+	}
+	return address, defaultPort, nil
+}
+
+// QueryStatus queries a Java server, falling back to Bedrock when nothing answers
+func QueryStatus(address string) (*Status, error) {
+	return Query(address, EditionAuto)
+}
+
+// Query asks the server at address for its status using the given edition.
+// EditionAuto tries Java first and Bedrock second
+func Query(address string, edition Edition) (*Status, error) {
+	switch edition {
+	case EditionJava:
+		return queryJava(address)
+	case EditionBedrock:
+		return queryBedrock(address)
+	case EditionAuto, "":
+		status, err := queryJava(address)
+		if err == nil {
+			return status, nil
+		}
+		if status, bedrockErr := queryBedrock(address); bedrockErr == nil {
+			return status, nil
+		}
+		return nil, err
+	default:
+		return nil, fmt.Errorf("unknown edition %q", edition)
+	}
+}
+
+// queryJava performs a server list ping, negotiating the protocol version and
+// falling back to the legacy ping for servers older than 1.7
+func queryJava(address string) (*Status, error) {
+	host, port, err := resolveAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	target := net.JoinHostPort(host, strconv.Itoa(int(port)))
+
+	var lastErr error
+	for _, protocol := range statusProtocols {
+		status, err := withConn(target, func(conn net.Conn) (*Status, error) {
+			return queryStatus(conn, host, port, protocol)
+		})
+		if err == nil {
+			status.Address = target
+			return status, nil
+		}
+		var dialErr *dialError
+		if errors.As(err, &dialErr) {
+			// nothing is listening, other versions will not fare better
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	status, err := withConn(target, func(conn net.Conn) (*Status, error) {
+		return queryLegacyStatus(conn, host, port)
+	})
+	if err != nil {
+		return nil, lastErr
+	}
+	status.Address = target
+	return status, nil
+}
+
+// dialError marks failures to connect at all, as opposed to protocol errors
+type dialError struct{ err error }
+
+func (e *dialError) Error() string { return fmt.Sprintf("cannot connect: %v", e.err) }
+func (e *dialError) Unwrap() error { return e.err }
+
+// withConn opens a TCP connection to target for the duration of query
+func withConn(target string, query func(net.Conn) (*Status, error)) (*Status, error) {
+	conn, err := net.DialTimeout("tcp", target, dialTimeout)
+	if err != nil {
+		return nil, &dialError{err}
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * dialTimeout))
+
+	return query(conn)
+}
+
+// queryStatus runs the status exchange over an open connection, so it can
+// also be driven by an in-process fake server
+func queryStatus(conn io.ReadWriter, host string, port uint16, protocol int32) (*Status, error) {
+	// Handshake with next state 1 (status), then the status request
+	handshake, err := (&mcproto.Encoder{}).
+		VarInt(protocol).
+		String(host).
+		UnsignedShort(port).
+		VarInt(1).
+		Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if err := mcproto.WritePacket(conn, mcproto.Packet{ID: 0x00, Data: handshake}); err != nil {
+		return nil, err
+	}
+	if err := mcproto.WritePacket(conn, mcproto.Packet{ID: 0x00}); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	packet, err := mcproto.ReadPacket(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status: %w", err)
+	}
+	if packet.ID != 0x00 {
+		return nil, fmt.Errorf("unexpected packet 0x%02x", packet.ID)
+	}
+	response, err := mcproto.NewDecoder(packet.Data).String(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status: %w", err)
+	}
+
+	var status Status
+	if err := json.Unmarshal([]byte(response), &status); err != nil {
+		return nil, fmt.Errorf("invalid status response: %w", err)
+	}
+	status.Edition = EditionJava
+
+	// Ping with the current time as payload, the server echoes it back
+	sent := time.Now()
+	ping, _ := (&mcproto.Encoder{}).Long(sent.UnixMilli()).Bytes()
+	if err := mcproto.WritePacket(conn, mcproto.Packet{ID: 0x01, Data: ping}); err != nil {
+		return &status, nil
+	}
+	if pong, err := mcproto.ReadPacket(r, 0); err == nil && pong.ID == 0x01 && bytes.Equal(pong.Data, ping) {
+		status.Latency = time.Since(sent)
+	}
+
+	return &status, nil
+}
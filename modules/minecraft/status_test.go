@@ -0,0 +1,219 @@
+package minecraft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf16"
+
+	"test/modules/mcproto"
+)
+
+const statusJSON = `{"version":{"name":"1.21","protocol":767},
+	"players":{"max":20,"online":2,"sample":[{"name":"Steve","id":"00000000-0000-0000-0000-000000000001"}]},
+	"description":{"text":"§aHello ","extra":[{"text":"world"}]}}`
+
+// reply returns a respond function for serveStatus that always sends response
+func reply(response string) func(handshake) *string {
+	return func(handshake) *string { return &response }
+}
+
+// handshake is what the fake server read from the client
+type handshake struct {
+	protocol int32
+	host     string
+	port     uint16
+}
+
+// serveStatus answers one status exchange on conn like a Java server would,
+// sending the response respond returns for the handshake. A nil response
+// closes the connection after the handshake instead.
+func serveStatus(conn net.Conn, respond func(handshake) *string) (handshake, error) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	var hs handshake
+	p, err := mcproto.ReadPacket(r, 0)
+	if err != nil {
+		return hs, err
+	}
+	d := mcproto.NewDecoder(p.Data)
+	if hs.protocol, err = d.VarInt(); err != nil {
+		return hs, err
+	}
+	if hs.host, err = d.String(255); err != nil {
+		return hs, err
+	}
+	if hs.port, err = d.UnsignedShort(); err != nil {
+		return hs, err
+	}
+	if next, err := d.VarInt(); err != nil || next != 1 {
+		return hs, errors.New("handshake does not ask for the status")
+	}
+	response := respond(hs)
+	if response == nil {
+		return hs, nil
+	}
+
+	if p, err := mcproto.ReadPacket(r, 0); err != nil || p.ID != 0x00 || len(p.Data) != 0 {
+		return hs, errors.New("missing status request")
+	}
+	body, err := (&mcproto.Encoder{}).String(*response).Bytes()
+	if err != nil {
+		return hs, err
+	}
+	if err := mcproto.WritePacket(conn, mcproto.Packet{ID: 0x00, Data: body}); err != nil {
+		return hs, err
+	}
+
+	ping, err := mcproto.ReadPacket(r, 0)
+	if err != nil || ping.ID != 0x01 {
+		return hs, errors.New("missing ping")
+	}
+	return hs, mcproto.WritePacket(conn, ping)
+}
+
+func TestQueryStatus(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	done := make(chan handshake, 1)
+	go func() {
+		hs, err := serveStatus(server, reply(statusJSON))
+		if err != nil {
+			t.Errorf("fake server: %v", err)
+		}
+		done <- hs
+	}()
+
+	status, err := queryStatus(client, "mc.example.com", 25565, 767)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := <-done
+
+	if hs.protocol != 767 || hs.host != "mc.example.com" || hs.port != 25565 {
+		t.Errorf("handshake = %+v", hs)
+	}
+	if status.Version.Name != "1.21" || status.Players.Online != 2 || status.Players.Max != 20 {
+		t.Errorf("status = %+v", status)
+	}
+	if len(status.Players.Sample) != 1 || status.Players.Sample[0].Name != "Steve" {
+		t.Errorf("player sample = %+v", status.Players.Sample)
+	}
+	if got := status.Description.String(); got != "Hello world" {
+		t.Errorf("description = %q, want %q", got, "Hello world")
+	}
+	if status.Edition != EditionJava || status.Latency <= 0 {
+		t.Errorf("edition %q, latency %v", status.Edition, status.Latency)
+	}
+}
+
+func TestQueryStatusRejectsMalformedResponses(t *testing.T) {
+	for _, response := range []string{"not json", `{"version":`, strings.Repeat("x", 40000)} {
+		client, server := net.Pipe()
+		go serveStatus(server, reply(response))
+
+		if _, err := queryStatus(client, "localhost", 25565, -1); err == nil {
+			t.Errorf("accepted response %.20q", response)
+		}
+		client.Close()
+	}
+}
+
+// listen starts a fake server on the loopback interface and returns its address
+func listen(t *testing.T, serve func(net.Conn)) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestQueryJavaNegotiatesProtocol(t *testing.T) {
+	var mu sync.Mutex
+	var tried []string
+	address := listen(t, func(conn net.Conn) {
+		// a proxy that only knows 1.16.5 drops every other handshake
+		serveStatus(conn, func(hs handshake) *string {
+			mu.Lock()
+			tried = append(tried, strconv.Itoa(int(hs.protocol)))
+			mu.Unlock()
+			if hs.protocol != 754 {
+				return nil
+			}
+			response := statusJSON
+			return &response
+		})
+	})
+
+	status, err := queryJava(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Address != address || status.Version.Name != "1.21" {
+		t.Errorf("status = %+v", status)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(tried, ","); got != "-1,767,754" {
+		t.Errorf("tried protocols %s, want -1,767,754", got)
+	}
+}
+
+func TestQueryJavaFallsBackToLegacyPing(t *testing.T) {
+	address := listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		first := make([]byte, 1)
+		if _, err := conn.Read(first); err != nil || first[0] != 0xFE {
+			// modern handshakes start with a length, old servers just hang up
+			return
+		}
+
+		kick := utf16.Encode([]rune("§1\x0078\x001.6.4\x00A Minecraft Server\x003\x0010"))
+		response := []byte{0xFF}
+		response = binary.BigEndian.AppendUint16(response, uint16(len(kick)))
+		response = appendUTF16(response, kick)
+		conn.Write(response)
+	})
+
+	status, err := queryJava(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Legacy || status.Version.Name != "1.6.4" || status.Players.Online != 3 || status.Players.Max != 10 {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestQueryJavaUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	var dialErr *dialError
+	if _, err := queryJava(address); !errors.As(err, &dialErr) {
+		t.Errorf("got %v, want a dial error", err)
+	}
+}
@@ -0,0 +1,447 @@
+package minecraft
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+	"test/modules/storage"
+)
+
+const (
+	// minWatchInterval keeps polling polite towards the watched servers
+	minWatchInterval = 1 * time.Minute
+	// maxWatchSamples is the number of status checks kept per server for stats
+	maxWatchSamples = 1440
+	// maxWatchesPerGuild limits how many servers a single guild can watch
+	maxWatchesPerGuild = 10
+	// maxConcurrentChecks limits how many servers are queried at once
+	maxConcurrentChecks = 4
+	// watchSaveInterval is how often check results without a status change are saved
+	watchSaveInterval = 10 * time.Minute
+)
+
+// Watch is a server a guild monitors
+type Watch struct {
+	GuildID   string        `json:"guild_id"`
+	ChannelID string        `json:"channel_id"`
+	Address   string        `json:"address"`
+	Interval  time.Duration `json:"interval"`
+	Threshold int           `json:"threshold"` // notify when the player count crosses it, 0 disables
+
+	Online     bool          `json:"online"`
+	Players    int           `json:"players"`
+	LastCheck  time.Time     `json:"last_check"`
+	LastChange time.Time     `json:"last_change"` // when the server last went up or down
+	Checks     int           `json:"checks"`
+	UpChecks   int           `json:"up_checks"`
+	Samples    []WatchSample `json:"samples"`
+	AddedAt    time.Time     `json:"added_at"`
+
+	checking bool
+}
+
+// WatchSample is the result of a single status check
+type WatchSample struct {
+	Time    time.Time `json:"time"`
+	Online  bool      `json:"online"`
+	Players int       `json:"players"`
+}
+
+var (
+	watchMutex sync.Mutex
+	watches    = make(map[string]*Watch)
+	// watchesDirty is set while check results have not been saved yet
+	watchesDirty bool
+	watchesSaved time.Time
+)
+
+func watchesPath() string {
+	return storage.Path("minecraft_watches.json")
+}
+
+func watchKey(guildID, address string) string {
+	return guildID + "/" + strings.ToLower(address)
+}
+
+// loadWatches reads the persisted watches
+func loadWatches() {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	if err := storage.Load(watchesPath(), &watches); err != nil {
+		log.Printf("Failed to load Minecraft watches: %v", err)
+	}
+	if watches == nil {
+		watches = make(map[string]*Watch)
+	}
+}
+
+// saveWatchesLocked persists all watches, watchMutex must be held
+func saveWatchesLocked() {
+	if err := storage.Save(watchesPath(), watches); err != nil {
+		log.Printf("Failed to save Minecraft watches: %v", err)
+		return
+	}
+	watchesDirty = false
+	watchesSaved = time.Now()
+}
+
+// SaveWatches saves check results that are waiting for watchSaveInterval.
+// The bot calls it before exiting
+func SaveWatches() {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	if watchesDirty {
+		saveWatchesLocked()
+	}
+}
+
+// runWatcher checks every watched server once its interval has passed
+func runWatcher(s discord.Client) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	limit := make(chan struct{}, maxConcurrentChecks)
+	for range ticker.C {
+		watchMutex.Lock()
+		var due []*Watch
+		for _, w := range watches {
+			if !w.checking && time.Since(w.LastCheck) >= w.Interval {
+				w.checking = true
+				due = append(due, w)
+			}
+		}
+		if watchesDirty && time.Since(watchesSaved) >= watchSaveInterval {
+			saveWatchesLocked()
+		}
+		watchMutex.Unlock()
+
+		for _, w := range due {
+			limit <- struct{}{}
+			go func(w *Watch) {
+				defer func() { <-limit }()
+				checkWatch(s, w)
+			}(w)
+		}
+	}
+}
+
+// checkWatch queries a watched server, records the result and posts a
+// notification when it went up or down or crossed the player threshold
+func checkWatch(s discord.Client, w *Watch) {
+	status, err := QueryStatus(w.Address)
+	online := err == nil
+	players := 0
+	if online {
+		players = status.Players.Online
+	}
+
+	watchMutex.Lock()
+	w.checking = false
+	if watches[watchKey(w.GuildID, w.Address)] != w {
+		// removed while the check was running
+		watchMutex.Unlock()
+		return
+	}
+
+	first := w.Checks == 0
+	wasOnline, previousPlayers := w.Online, w.Players
+
+	now := time.Now()
+	w.LastCheck = now
+	w.Checks++
+	if online {
+		w.UpChecks++
+	}
+	w.Online, w.Players = online, players
+	w.Samples = append(w.Samples, WatchSample{Time: now, Online: online, Players: players})
+	if len(w.Samples) > maxWatchSamples {
+		w.Samples = w.Samples[len(w.Samples)-maxWatchSamples:]
+	}
+
+	var event string
+	switch {
+	case first:
+		w.LastChange = now
+	case online != wasOnline:
+		w.LastChange = now
+		if online {
+			event = "🟢 Server is back online"
+		} else {
+			event = "🔴 Server went offline"
+		}
+	case online && w.Threshold > 0 && previousPlayers < w.Threshold && players >= w.Threshold:
+		event = fmt.Sprintf("📈 Player count reached %d", w.Threshold)
+	case online && w.Threshold > 0 && previousPlayers >= w.Threshold && players < w.Threshold:
+		event = fmt.Sprintf("📉 Player count dropped below %d", w.Threshold)
+	}
+	channelID, address := w.ChannelID, w.Address
+	// the samples of every check are saved with the next status change or
+	// within watchSaveInterval
+	if first || event != "" {
+		saveWatchesLocked()
+	} else {
+		watchesDirty = true
+	}
+	watchMutex.Unlock()
+
+	if event == "" {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     event,
+		Color:     0xFF5555,
+		Timestamp: now.Format(time.RFC3339),
+		Footer:    &discordgo.MessageEmbedFooter{Text: address},
+	}
+	if online {
+		embed, _ = buildStatusEmbed(address, status)
+		embed.Title = event
+		embed.Thumbnail = nil
+	} else {
+		embed.Description = fmt.Sprintf("**%s** is not answering: %v", address, err)
+	}
+
+	if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+		log.Printf("Failed to post watch notification for %s: %v", address, err)
+	}
+}
+
+// handleWatchCommand processes /mcwatch add|remove|list|stats. Who may use the
+// subcommands is declared by WatchAccess and can be changed per server with
+// /permissions
+func handleWatchCommand(s discord.Client, i *discordgo.InteractionCreate) {
+	if !isWatchCommand(i) {
+		return
+	}
+
+	if i.GuildID == "" {
+		discord.RespondEphemeral(s, i, "❌ Servers can only be watched from a Discord server")
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		discord.RespondEphemeral(s, i, "❌ Please choose a subcommand")
+		return
+	}
+	sub := options[0]
+
+	switch sub.Name {
+	case "add":
+		addWatch(s, i, sub)
+	case "remove":
+		removeWatch(s, i, optionString(sub, "address"))
+	case "list":
+		listWatches(s, i)
+	case "stats":
+		watchStats(s, i, optionString(sub, "address"))
+	}
+}
+
+func addWatch(s discord.Client, i *discordgo.InteractionCreate, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	address := optionString(sub, "address")
+	if _, _, err := resolveAddress(address); err != nil {
+		discord.RespondEphemeral(s, i, fmt.Sprintf("❌ Invalid address: %v", err))
+		return
+	}
+
+	w := &Watch{
+		GuildID:   i.GuildID,
+		ChannelID: i.ChannelID,
+		Address:   address,
+		Interval:  5 * time.Minute,
+		AddedAt:   time.Now(),
+	}
+	for _, option := range sub.Options {
+		switch option.Name {
+		case "channel":
+			w.ChannelID = option.ChannelValue(nil).ID
+		case "interval":
+			w.Interval = max(time.Duration(option.IntValue())*time.Minute, minWatchInterval)
+		case "threshold":
+			w.Threshold = int(option.IntValue())
+		}
+	}
+
+	watchMutex.Lock()
+	count := 0
+	for _, existing := range watches {
+		if existing.GuildID == i.GuildID {
+			count++
+		}
+	}
+	key := watchKey(w.GuildID, w.Address)
+	_, exists := watches[key]
+	if !exists && count >= maxWatchesPerGuild {
+		watchMutex.Unlock()
+		discord.RespondEphemeral(s, i, fmt.Sprintf("❌ A server can watch at most %d Minecraft servers", maxWatchesPerGuild))
+		return
+	}
+	watches[key] = w
+	saveWatchesLocked()
+	watchMutex.Unlock()
+
+	message := fmt.Sprintf("👀 Watching **%s** every %v, notifications go to <#%s>", w.Address, w.Interval, w.ChannelID)
+	if w.Threshold > 0 {
+		message += fmt.Sprintf(" (player threshold %d)", w.Threshold)
+	}
+	if exists {
+		message += "\nThe previous settings and history for this server were replaced"
+	}
+	discord.Respond(s, i, message)
+}
+
+func removeWatch(s discord.Client, i *discordgo.InteractionCreate, address string) {
+	key := watchKey(i.GuildID, address)
+
+	watchMutex.Lock()
+	_, exists := watches[key]
+	delete(watches, key)
+	if exists {
+		saveWatchesLocked()
+	}
+	watchMutex.Unlock()
+
+	if !exists {
+		discord.RespondEphemeral(s, i, fmt.Sprintf("❌ **%s** is not being watched", address))
+		return
+	}
+	discord.Respond(s, i, fmt.Sprintf("🛑 Stopped watching **%s**", address))
+}
+
+func listWatches(s discord.Client, i *discordgo.InteractionCreate) {
+	watchMutex.Lock()
+	var lines []string
+	for _, w := range watches {
+		if w.GuildID != i.GuildID {
+			continue
+		}
+		state := "⚪ not checked yet"
+		if w.Checks > 0 && w.Online {
+			state = fmt.Sprintf("🟢 %d players", w.Players)
+		} else if w.Checks > 0 {
+			state = "🔴 offline"
+		}
+		lines = append(lines, fmt.Sprintf("**%s** — %s · every %v · <#%s>", w.Address, state, w.Interval, w.ChannelID))
+	}
+	watchMutex.Unlock()
+
+	if len(lines) == 0 {
+		discord.Respond(s, i, "No Minecraft servers are being watched, add one with `/mcwatch add`")
+		return
+	}
+	sort.Strings(lines)
+	discord.Respond(s, i, strings.Join(lines, "\n"))
+}
+
+func watchStats(s discord.Client, i *discordgo.InteractionCreate, address string) {
+	watchMutex.Lock()
+	w, exists := watches[watchKey(i.GuildID, address)]
+	var embed *discordgo.MessageEmbed
+	if exists {
+		embed = buildStatsEmbed(w)
+	}
+	watchMutex.Unlock()
+
+	if !exists {
+		discord.RespondEphemeral(s, i, fmt.Sprintf("❌ **%s** is not being watched", address))
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
+
+// buildStatsEmbed summarizes the uptime history of a watch, watchMutex must be held
+func buildStatsEmbed(w *Watch) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("📊 %s", w.Address),
+		Color:  0x55FF55,
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Watched since %s", w.AddedAt.Format("2006-01-02"))},
+	}
+	if w.Checks == 0 {
+		embed.Description = "Not checked yet"
+		return embed
+	}
+
+	current := "🔴 Offline"
+	if w.Online {
+		current = fmt.Sprintf("🟢 Online, %d players", w.Players)
+	} else {
+		embed.Color = 0xFF5555
+	}
+
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: "Status", Value: fmt.Sprintf("%s since <t:%d:R>", current, w.LastChange.Unix()), Inline: false},
+		{Name: "Uptime (all time)", Value: fmt.Sprintf("%.2f%% of %d checks", percent(w.UpChecks, w.Checks), w.Checks), Inline: true},
+	}
+
+	var day, dayUp, peak, total, online int
+	cutoff := time.Now().Add(-24 * time.Hour)
+	for _, sample := range w.Samples {
+		if sample.Online {
+			online++
+			total += sample.Players
+			peak = max(peak, sample.Players)
+		}
+		if sample.Time.After(cutoff) {
+			day++
+			if sample.Online {
+				dayUp++
+			}
+		}
+	}
+	if day > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Uptime (24h)", Value: fmt.Sprintf("%.2f%%", percent(dayUp, day)), Inline: true,
+		})
+	}
+	if online > 0 {
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{Name: "Average players", Value: fmt.Sprintf("%.1f", float64(total)/float64(online)), Inline: true},
+			&discordgo.MessageEmbedField{Name: "Peak players", Value: fmt.Sprintf("%d", peak), Inline: true},
+		)
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name: "Last check", Value: fmt.Sprintf("<t:%d:R>", w.LastCheck.Unix()), Inline: true,
+	})
+	return embed
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+func isWatchCommand(i *discordgo.InteractionCreate) bool {
+	return i != nil &&
+		i.Interaction != nil &&
+		i.Type == discordgo.InteractionApplicationCommand &&
+		i.ApplicationCommandData().Name == "mcwatch"
+}
+
+// optionString gets a string parameter from subcommand options
+func optionString(sub *discordgo.ApplicationCommandInteractionDataOption, name string) string {
+	for _, option := range sub.Options {
+		if option.Name == name {
+			return strings.TrimSpace(option.StringValue())
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package permissions
+
+import (
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+const commandName = "permissions"
+
+// PermissionsAccess limits /permissions to members who manage the server
+var PermissionsAccess = discord.Access{Permissions: discordgo.PermissionManageGuild}
+
+// permissionChoices are the requirements admins can pick for a command
+var permissionChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "Everyone", Value: "0"},
+	{Name: "Send Messages", Value: strconv.FormatInt(discordgo.PermissionSendMessages, 10)},
+	{Name: "Attach Files", Value: strconv.FormatInt(discordgo.PermissionAttachFiles, 10)},
+	{Name: "Manage Messages", Value: strconv.FormatInt(discordgo.PermissionManageMessages, 10)},
+	{Name: "Timeout Members", Value: strconv.FormatInt(discordgo.PermissionModerateMembers, 10)},
+	{Name: "Manage Channels", Value: strconv.FormatInt(discordgo.PermissionManageChannels, 10)},
+	{Name: "Manage Server", Value: strconv.FormatInt(discordgo.PermissionManageGuild, 10)},
+	{Name: "Administrator", Value: strconv.FormatInt(discordgo.PermissionAdministrator, 10)},
+}
+
+// newPermissionsCommand builds /permissions offering the given command names
+func newPermissionsCommand(commands []string) *discordgo.ApplicationCommand {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(commands))
+	for _, name := range commands {
+		if len(choices) == 25 {
+			break // Discord's limit
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: "/" + name, Value: name})
+	}
+
+	command := func(required bool) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "command",
+			Description: "The command to configure",
+			Required:    required,
+			Choices:     choices,
+		}
+	}
+	role := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionRole,
+		Name:        "role",
+		Description: "The role",
+		Required:    true,
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:        commandName,
+		Description: "Control who can use the bot's commands on this server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show who can use the commands",
+				Options:     []*discordgo.ApplicationCommandOption{command(false)},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "require",
+				Description: "Set the permission members need to use a command",
+				Options: []*discordgo.ApplicationCommandOption{
+					command(true),
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "permission",
+						Description: "The permission required",
+						Required:    true,
+						Choices:     permissionChoices,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "allow-role",
+				Description: "Limit a command to members with one of the allowed roles",
+				Options:     []*discordgo.ApplicationCommandOption{command(true), role},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove-role",
+				Description: "Remove a role from the allowed roles of a command",
+				Options:     []*discordgo.ApplicationCommandOption{command(true), role},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Restore the default access of a command",
+				Options:     []*discordgo.ApplicationCommandOption{command(true)},
+			},
+		},
+	}
+}
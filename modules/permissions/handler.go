@@ -0,0 +1,166 @@
+package permissions
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+)
+
+var (
+	router *discord.Router
+	policy *Policy
+)
+
+// SyncCommand, when set, updates the definition Discord has of a command in a
+// guild after its access there changed, so the command list only shows it to
+// members who may use it. Without it, or for commands registered globally,
+// the override is only enforced when the command is used
+var SyncCommand func(guildID string, def *discordgo.ApplicationCommand) error
+
+// RegisterPermissionsHandler adds /permissions and makes r enforce the
+// overrides made with it. Register it after all other modules, so it can
+// offer their commands
+func RegisterPermissionsHandler(r *discord.Router) {
+	var names []string
+	for _, def := range r.Commands() {
+		names = append(names, def.Name)
+	}
+
+	router = r
+	policy = LoadPolicy()
+	r.SetPolicy(policy)
+
+	def := newPermissionsCommand(names)
+	r.Command(def, handlePermissionsCommand)
+	r.Restrict(def, PermissionsAccess)
+}
+
+// handlePermissionsCommand processes /permissions show|require|allow-role|remove-role|reset
+func handlePermissionsCommand(s discord.Client, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		discord.RespondEphemeral(s, i, "❌ Permissions can only be configured on a server")
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		discord.RespondEphemeral(s, i, "❌ Please choose a subcommand")
+		return
+	}
+	sub := options[0]
+
+	var command, permission, roleID string
+	for _, option := range sub.Options {
+		switch option.Name {
+		case "command":
+			command = option.StringValue()
+		case "permission":
+			permission = option.StringValue()
+		case "role":
+			roleID = option.RoleValue(nil, i.GuildID).ID
+		}
+	}
+	if command == commandName {
+		discord.RespondEphemeral(s, i, "❌ The access to /permissions can not be changed")
+		return
+	}
+
+	var err error
+	switch sub.Name {
+	case "show":
+		discord.RespondEphemeral(s, i, describeAccess(i.GuildID, command))
+		return
+	case "require":
+		var bits int64
+		if bits, err = strconv.ParseInt(permission, 10, 64); err != nil {
+			discord.RespondEphemeral(s, i, "❌ Unknown permission")
+			return
+		}
+		err = policy.update(i.GuildID, command, func(o *Override) {
+			o.Permissions = &bits
+		})
+	case "allow-role":
+		err = policy.update(i.GuildID, command, func(o *Override) {
+			if !slices.Contains(o.Roles, roleID) {
+				o.Roles = append(o.Roles, roleID)
+			}
+		})
+	case "remove-role":
+		err = policy.update(i.GuildID, command, func(o *Override) {
+			o.Roles = slices.DeleteFunc(o.Roles, func(id string) bool { return id == roleID })
+		})
+	case "reset":
+		err = policy.reset(i.GuildID, command)
+	}
+	if err != nil {
+		log.Printf("Failed to save permissions of guild %s: %v", i.GuildID, err)
+		discord.RespondEphemeral(s, i, "❌ Failed to save the permissions, please try again")
+		return
+	}
+
+	reply := "✅ Updated\n" + describeAccess(i.GuildID, command)
+	if err := syncCommand(i.GuildID, command); err != nil {
+		log.Printf("Failed to sync /%s in guild %s: %v", command, i.GuildID, err)
+		reply += "\n⚠️ Discord's command list may still show the old permissions, they are enforced anyway"
+	}
+	discord.RespondEphemeral(s, i, reply)
+}
+
+// syncCommand passes the definition of command with the access in guildID to SyncCommand
+func syncCommand(guildID, command string) error {
+	if SyncCommand == nil {
+		return nil
+	}
+	for _, def := range router.CommandsFor(guildID) {
+		if def.Name == command {
+			return SyncCommand(guildID, def)
+		}
+	}
+	return nil
+}
+
+// describeAccess lists who may use command, or every command when it is empty
+func describeAccess(guildID, command string) string {
+	var lines []string
+	for _, def := range router.Commands() {
+		if command != "" && def.Name != command {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("**/%s**: %s", def.Name, describe(router.Access(guildID, def.Name))))
+		for _, sub := range router.RestrictedSubcommands(def.Name) {
+			lines = append(lines, fmt.Sprintf("**/%s %s**: %s", def.Name, sub, describe(router.SubcommandAccess(guildID, def.Name, sub))))
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("❌ Unknown command /%s", command)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func describe(a discord.Access) string {
+	if a.OwnerOnly {
+		return "bot owner only"
+	}
+
+	var parts []string
+	if a.Permissions != 0 {
+		parts = append(parts, "needs "+discord.PermissionNames(a.Permissions))
+	}
+	if len(a.Roles) > 0 {
+		roles := make([]string, len(a.Roles))
+		for n, id := range a.Roles {
+			roles[n] = "<@&" + id + ">"
+		}
+		parts = append(parts, "limited to "+strings.Join(roles, ", "))
+	}
+	if len(parts) == 0 {
+		return "everyone"
+	}
+	return strings.Join(parts, ", ")
+}
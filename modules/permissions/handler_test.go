@@ -0,0 +1,77 @@
+package permissions
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"test/modules/discord"
+	"test/modules/storage"
+)
+
+func permissionsCommand(sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "i1",
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: "g1",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "admin"}, Permissions: discordgo.PermissionManageGuild},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: commandName,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand, Options: options},
+			},
+		},
+	}}
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+func TestChangesAreSynced(t *testing.T) {
+	dir := storage.Dir
+	storage.Dir = t.TempDir()
+	t.Cleanup(func() { storage.Dir = dir })
+
+	r := discord.NewRouter(discord.NewRecorder())
+	r.Command(&discordgo.ApplicationCommand{Name: "track"}, func(discord.Client, *discordgo.InteractionCreate) {})
+	RegisterPermissionsHandler(r)
+
+	var synced []*discordgo.ApplicationCommand
+	SyncCommand = func(guildID string, def *discordgo.ApplicationCommand) error {
+		if guildID != "g1" {
+			t.Errorf("synced guild %s", guildID)
+		}
+		synced = append(synced, def)
+		return nil
+	}
+	t.Cleanup(func() { SyncCommand = nil })
+
+	rec := discord.NewRecorder()
+	bits := strconv.FormatInt(discordgo.PermissionManageMessages, 10)
+	handlePermissionsCommand(rec, permissionsCommand("require", stringOption("command", "track"), stringOption("permission", bits)))
+
+	if len(synced) != 1 || synced[0].Name != "track" {
+		t.Fatalf("synced %+v, want /track", synced)
+	}
+	if p := synced[0].DefaultMemberPermissions; p == nil || *p != discordgo.PermissionManageMessages {
+		t.Errorf("synced default permissions %v, want Manage Messages", p)
+	}
+
+	// showing the access changes nothing on Discord
+	handlePermissionsCommand(rec, permissionsCommand("show"))
+	if len(synced) != 1 {
+		t.Errorf("show synced %d commands", len(synced)-1)
+	}
+
+	handlePermissionsCommand(rec, permissionsCommand("reset", stringOption("command", "track")))
+	if len(synced) != 2 || synced[1].DefaultMemberPermissions != nil {
+		t.Errorf("reset synced %+v, want the declared access", synced[len(synced)-1])
+	}
+	calls := rec.Calls("InteractionRespond")
+	if content := calls[len(calls)-1].Args[1].(*discordgo.InteractionResponse).Data.Content; !strings.Contains(content, "everyone") {
+		t.Errorf("reply %q", content)
+	}
+}
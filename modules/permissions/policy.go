@@ -0,0 +1,98 @@
+package permissions
+
+import (
+	"log"
+	"slices"
+	"sync"
+
+	"test/modules/discord"
+	"test/modules/storage"
+)
+
+// Override replaces parts of the declared access of a command in one guild
+type Override struct {
+	Permissions *int64   `json:"permissions,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+}
+
+// Policy applies the overrides guild admins made with /permissions
+type Policy struct {
+	mu        sync.Mutex
+	overrides map[string]map[string]*Override // guild ID -> command -> override
+}
+
+var _ discord.AccessPolicy = (*Policy)(nil)
+
+func overridesPath() string {
+	return storage.Path("permissions.json")
+}
+
+// LoadPolicy reads the persisted overrides
+func LoadPolicy() *Policy {
+	p := &Policy{overrides: make(map[string]map[string]*Override)}
+	if err := storage.Load(overridesPath(), &p.overrides); err != nil {
+		log.Printf("Failed to load command permissions: %v", err)
+	}
+	if p.overrides == nil {
+		p.overrides = make(map[string]map[string]*Override)
+	}
+	return p
+}
+
+// Access applies the guild's override for command to the declared access.
+// Owner-only commands and /permissions itself can not be changed
+func (p *Policy) Access(guildID, command string, declared discord.Access) discord.Access {
+	if declared.OwnerOnly || command == commandName {
+		return declared
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	o := p.overrides[guildID][command]
+	if o == nil {
+		return declared
+	}
+	if o.Permissions != nil {
+		declared.Permissions = *o.Permissions
+	}
+	if len(o.Roles) > 0 {
+		declared.Roles = slices.Clone(o.Roles)
+	}
+	return declared
+}
+
+// update changes the override of command in guildID and persists all overrides.
+// Overrides left empty are removed
+func (p *Policy) update(guildID, command string, change func(o *Override)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	guild := p.overrides[guildID]
+	if guild == nil {
+		guild = make(map[string]*Override)
+		p.overrides[guildID] = guild
+	}
+	o := guild[command]
+	if o == nil {
+		o = &Override{}
+	}
+	change(o)
+
+	if o.Permissions == nil && len(o.Roles) == 0 {
+		delete(guild, command)
+	} else {
+		guild[command] = o
+	}
+	if len(guild) == 0 {
+		delete(p.overrides, guildID)
+	}
+	return storage.Save(overridesPath(), p.overrides)
+}
+
+// reset removes the override of command in guildID
+func (p *Policy) reset(guildID, command string) error {
+	return p.update(guildID, command, func(o *Override) {
+		*o = Override{}
+	})
+}
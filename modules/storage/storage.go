@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dir is the directory persisted module data is kept in
+var Dir = "./data"
+
+// Path returns the location of a data file inside Dir
+func Path(name string) string {
+	return filepath.Join(Dir, name)
+}
+
+// Load reads the JSON file at path into v. A missing file is not an error
+// and leaves v untouched.
+func Load(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid data in %s: %w", path, err)
+	}
+	return nil
+}
+
+// Save writes v as JSON to path, replacing the file atomically so a crash
+// never leaves a half written file behind
+func Save(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}